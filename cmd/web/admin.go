@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// parseLogLevel converts a level name used by the -log-level flag and the
+// admin endpoint into a slog.Level.
+func parseLogLevel(s string) (slog.Level, error) {
+	switch s {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("invalid log level %q: must be debug, info, warn or error", s)
+	}
+}
+
+// adminDBStats renders the connection pool statistics page so pool
+// exhaustion can be diagnosed without a monitoring stack.
+func (app *application) adminDBStats(w http.ResponseWriter, r *http.Request) {
+	stats := app.db.Stats()
+
+	data := app.newTemplateData(r)
+	data.DBStats = &stats
+	data.Breadcrumbs = app.breadcrumbs(r, breadcrumb{Label: "Database Statistics"})
+
+	app.render(w, r, http.StatusOK, "dbstats.tmpl", data)
+}
+
+// adminJobs renders the background job dashboard, showing when each
+// scheduled job last ran and whether it failed.
+func (app *application) adminJobs(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	data.Jobs = app.scheduler.Status()
+	data.Breadcrumbs = app.breadcrumbs(r, breadcrumb{Label: "Background Jobs"})
+
+	app.render(w, r, http.StatusOK, "jobs.tmpl", data)
+}
+
+// maxAdminTasks caps how many queued tasks the admin page lists.
+const maxAdminTasks = 50
+
+// adminTasks lists the most recent queue tasks — pending, delivered and
+// dead-lettered — so email deliveries and their failures can be
+// inspected.
+func (app *application) adminTasks(w http.ResponseWriter, r *http.Request) {
+	recent, err := app.taskQueue.Recent(maxAdminTasks)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Tasks = recent
+	data.Breadcrumbs = app.breadcrumbs(r, breadcrumb{Label: "Background Tasks"})
+
+	app.render(w, r, http.StatusOK, "tasks.tmpl", data)
+}
+
+// adminLogLevel lets an administrator change the minimum log level at
+// runtime, so debug logging can be enabled temporarily in production
+// without a restart.
+func (app *application) adminLogLevel(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, formErrorStatus(err))
+		return
+	}
+
+	level, err := parseLogLevel(r.PostForm.Get("level"))
+	if err != nil {
+		app.clientError(w, http.StatusUnprocessableEntity)
+		return
+	}
+
+	app.logLevel.Set(level)
+
+	app.logger.Info("log level changed", "level", level)
+
+	fmt.Fprintf(w, "log level set to %s\n", level)
+}
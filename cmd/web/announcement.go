@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/validator"
+)
+
+// sessionKeyDismissedAnnouncement holds the hash of the announcement
+// the user dismissed, so the banner stays hidden for them until the
+// text changes.
+const sessionKeyDismissedAnnouncement = "dismissedAnnouncement"
+
+// announcementDateLayout is how the optional expiry date is entered and
+// stored.
+const announcementDateLayout = "2006-01-02"
+
+// announcementLevels are the banner styles an admin can pick from; they
+// map onto the flash message styling.
+var announcementLevels = []string{flashLevelSuccess, flashLevelWarning, flashLevelError}
+
+// announcement is the site-wide banner as the base layout renders it.
+type announcement struct {
+	Text  string
+	Level string
+	Hash  string
+}
+
+// announcementHash fingerprints the banner text, so a dismissal stops
+// applying once an admin changes the announcement.
+func announcementHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// announcement returns the banner to show this request, or nil when
+// there is none: no text configured, the expiry date has passed, or the
+// user already dismissed this announcement. The banner stays up through
+// the whole expiry day.
+func (app *application) announcement(r *http.Request) *announcement {
+	s := app.siteSettings()
+	if s.Announcement == "" {
+		return nil
+	}
+
+	if s.AnnouncementExpires != "" {
+		t, ok := validator.Date(s.AnnouncementExpires, announcementDateLayout)
+		if !ok || time.Now().UTC().After(t.AddDate(0, 0, 1)) {
+			return nil
+		}
+	}
+
+	a := &announcement{
+		Text:  s.Announcement,
+		Level: s.AnnouncementLevel,
+		Hash:  announcementHash(s.Announcement),
+	}
+
+	if app.sessionManager.GetString(r.Context(), sessionKeyDismissedAnnouncement) == a.Hash {
+		return nil
+	}
+
+	return a
+}
+
+// announcementDismissPost hides the current announcement for this user
+// by remembering its hash in the session.
+func (app *application) announcementDismissPost(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, formErrorStatus(err))
+		return
+	}
+
+	if s := app.siteSettings(); s.Announcement != "" {
+		app.sessionManager.Put(r.Context(), sessionKeyDismissedAnnouncement, announcementHash(s.Announcement))
+	}
+
+	http.Redirect(w, r, app.path("/"), http.StatusSeeOther)
+}
@@ -0,0 +1,19 @@
+package main
+
+import "net/http"
+
+// breadcrumb is one link in the breadcrumb trail. The final crumb is
+// the current page and carries no URL.
+type breadcrumb struct {
+	Label string
+	URL   string
+}
+
+// breadcrumbs builds a trail starting at Home, for handlers of pages
+// deep enough to need one. Crumb URLs should come through app.path so
+// they respect the configured base path.
+func (app *application) breadcrumbs(r *http.Request, crumbs ...breadcrumb) []breadcrumb {
+	trail := make([]breadcrumb, 0, len(crumbs)+1)
+	trail = append(trail, breadcrumb{Label: app.t(r, "nav.home"), URL: app.path("/")})
+	return append(trail, crumbs...)
+}
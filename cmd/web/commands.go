@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/i18n"
+	"snippetbox.ayubasayyed.net/internal/jobs"
+
+	"snippetbox.ayubasayyed.net/internal/migrations"
+	"snippetbox.ayubasayyed.net/internal/models"
+	"snippetbox.ayubasayyed.net/internal/tasks"
+	"snippetbox.ayubasayyed.net/internal/validator"
+
+	"golang.org/x/term"
+)
+
+// migrateCmd runs database migrations: migrate [flags] up|down|status.
+func migrateCmd(args []string) {
+	cfg, rest := parseConfig(args, nil)
+
+	if len(rest) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: migrate [flags] up|down|status")
+		os.Exit(2)
+	}
+	action := rest[0]
+
+	logger, _, err := newLogger(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	switch action {
+	case "up":
+		n, err := migrations.Up(db, cfg.DB.Driver)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		logger.Info("migrations applied", "count", n)
+	case "down":
+		n, err := migrations.Down(db, cfg.DB.Driver)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		logger.Info("migrations rolled back", "count", n)
+	case "status":
+		status, err := migrations.Status(db, cfg.DB.Driver)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		for _, m := range status {
+			state := "pending"
+			if m.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%04d_%s\t%s\n", m.Version, m.Name, state)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "usage: migrate [flags] up|down|status")
+		os.Exit(2)
+	}
+}
+
+// createadminCmd creates an admin user, prompting for the password, so a
+// fresh install has a way to reach the admin UI without hand-editing SQL.
+// If a user with the email already exists they are promoted to admin
+// instead (leaving their password unchanged).
+func createadminCmd(args []string) {
+	var email, name string
+	cfg, _ := parseConfig(args, func(fs *flag.FlagSet) {
+		fs.StringVar(&email, "email", "", "Email address of the admin user")
+		fs.StringVar(&name, "name", "", "Display name of the admin user")
+	})
+
+	logger, _, err := newLogger(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if !validator.EmailRX.MatchString(email) {
+		fmt.Fprintln(os.Stderr, "createadmin: -email must be a valid email address")
+		os.Exit(2)
+	}
+	if name == "" {
+		fmt.Fprintln(os.Stderr, "createadmin: -name must not be empty")
+		os.Exit(2)
+	}
+
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	users := &models.UserModel{DB: db}
+
+	// If the user already exists, promote them without asking for a
+	// password.
+	err = users.MakeAdmin(email)
+	if err == nil {
+		logger.Info("existing user promoted to admin", "email", email)
+		return
+	}
+	if !errors.Is(err, models.ErrNoRecord) {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	password, err := promptPassword()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	if err := users.Insert(name, email, password); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	if err := users.MakeAdmin(email); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	logger.Info("admin user created", "email", email)
+}
+
+// promptPassword reads the new password twice without echo when stdin is a
+// terminal, falling back to reading a single line otherwise so the command
+// can be scripted.
+func promptPassword() (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		return checkPassword(strings.TrimRight(line, "\r\n"))
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprint(os.Stderr, "Confirm password: ")
+	confirm, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	if string(password) != string(confirm) {
+		return "", errors.New("passwords do not match")
+	}
+
+	return checkPassword(string(password))
+}
+
+// checkPassword applies the same minimum length the signup form enforces.
+func checkPassword(password string) (string, error) {
+	if !validator.MinChars(password, 8) {
+		return "", errors.New("password must be at least 8 characters long")
+	}
+	return password, nil
+}
+
+// checkTemplatesCmd parses every page template against the base layout
+// and executes it with representative data, so a broken template fails
+// the build or deploy instead of a live request.
+func checkTemplatesCmd() {
+	translator, err := i18n.New()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	cache, err := newTemplateCache("", "", translator)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for name, ts := range cache {
+		if err := ts.ExecuteTemplate(io.Discard, "base", sampleTemplateData(name, time.Now())); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+
+	fmt.Printf("%d templates OK\n", len(cache))
+}
+
+// sampleTemplateData builds a templateData with every field populated the
+// way the page's handler would, so execution walks the same paths a real
+// request does. Timestamps derive from now, which the golden-file tests
+// pin to a fixed instant for reproducible output.
+func sampleTemplateData(page string, now time.Time) *templateData {
+
+	snippet := &models.Snippet{
+		ID:      1,
+		Title:   "An old silent pond",
+		Content: "An old silent pond...",
+		Created: now,
+		Expires: now.AddDate(0, 0, 365),
+	}
+
+	data := &templateData{
+		CurrentYear:  now.Year(),
+		SiteName:     defaultSiteSettings().SiteName,
+		CanonicalURL: "https://example.com/snippet/view/1",
+		Locale:       "en",
+		Locales:      []string{"en", "de", "fr"},
+		Clock:        clock{loc: time.UTC, layout: "02 Jan 2006 at 15:04"},
+		Timezone:     "UTC",
+		Timezones:    commonTimezones,
+		Theme:        themeDefault,
+		Themes:       themes,
+		Announcement: &announcement{Text: "Scheduled maintenance on Sunday.", Level: flashLevelWarning, Hash: "abc123"},
+		Breadcrumbs:  []breadcrumb{{Label: "Home", URL: "/"}, {Label: "Snippet #1"}},
+		Snippet:      snippet,
+		Snippets:     []*models.Snippet{snippet},
+		Pagination:   &Pagination{Page: 2, TotalPages: 3, PrevURL: "/", NextURL: "/?page=3"},
+		Comments: []*models.Comment{
+			{ID: 1, SnippetID: 1, UserID: 1, Author: "Alice", Body: "Nice one", Created: now},
+		},
+		Status: &statusData{
+			Uptime:    time.Minute,
+			Version:   "dev",
+			Commit:    "none",
+			Requests:  1,
+			DBHealthy: true,
+		},
+		DBStats:    &sql.DBStats{},
+		Jobs:       []jobs.Status{{Name: "purge", Interval: time.Hour, LastRun: now, Runs: 1}},
+		CSPReports: []cspReport{{DocumentURI: "https://example.com/", ViolatedDirective: "script-src", BlockedURI: "eval", Received: now}},
+		ContactMessages: []*models.ContactMessage{
+			{ID: 1, Name: "Alice", Email: "alice@example.com", Message: "Hello there", Created: now},
+		},
+		Flashes: []flashMessage{
+			{Level: flashLevelSuccess, Message: "Flash message"},
+			{Level: flashLevelWarning, Message: "Warning message"},
+		},
+		IsAuthenticated: true,
+		ErrorReference:  "ABC123",
+	}
+
+	switch page {
+	case "ip-filter.tmpl":
+		data.Form = ipFilterForm{Allow: "10.0.0.0/8", Block: "192.0.2.0/24"}
+	case "settings.tmpl":
+		data.Form = settingsForm{
+			SiteName:          "Snippetbox",
+			DefaultExpires:    365,
+			SignupOpen:        true,
+			MaxSnippetSize:    10000,
+			AnnouncementLevel: flashLevelWarning,
+		}
+	case "create.tmpl":
+		data.Form = snippetCreateForm{}
+	case "view.tmpl":
+		data.Form = commentCreateForm{}
+		data.Reactions = []reactionRow{{Emoji: "\U0001F44D", Count: 2, Mine: true}}
+	case "signup.tmpl":
+		data.Form = userSignupForm{}
+	case "login.tmpl":
+		data.Form = userLoginForm{}
+	case "page.tmpl":
+		data.Page = &staticPage{Slug: "about", Title: "About", HTML: "<p>About this site.</p>"}
+	case "contact.tmpl":
+		data.Form = contactForm{}
+	case "profile.tmpl":
+		data.Profile = &models.User{ID: 1, Name: "Alice", Email: "alice@example.com", Created: now}
+	case "notifications.tmpl":
+		data.Notifications = []*models.Notification{
+			{ID: 1, UserID: 1, Kind: models.NotificationComment, Actor: "Bob", SnippetID: 1, Created: now},
+		}
+	case "tasks.tmpl":
+		data.Tasks = []tasks.TaskInfo{
+			{ID: 1, Kind: emailTaskKind, Attempts: 1, Created: now, Done: true},
+			{ID: 2, Kind: emailTaskKind, Attempts: 5, Created: now, Dead: true, LastError: "connection refused"},
+		}
+	case "notification-prefs.tmpl":
+		data.NotificationPrefs = []notificationPrefRow{
+			{Event: models.NotificationComment, InApp: true, Email: true},
+		}
+	}
+
+	return data
+}
+
+// seedCmd migrates the database if needed and inserts a few example
+// snippets, so a fresh development install has content to render.
+func seedCmd(args []string) {
+	cfg, _ := parseConfig(args, nil)
+
+	logger, _, err := newLogger(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if _, err := migrations.Up(db, cfg.DB.Driver); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	snippets := &models.SnippetModel{DB: db}
+
+	seeds := []struct {
+		title   string
+		content string
+	}{
+		{"An old silent pond", "An old silent pond...\nA frog jumps into the pond,\nsplash! Silence again.\n\n– Matsuo Bashō"},
+		{"Over the wintry forest", "Over the wintry\nforest, winds howl in rage\nwith no leaves to blow.\n\n– Natsume Soseki"},
+		{"First autumn morning", "First autumn morning\nthe mirror I stare into\nshows my father's face.\n\n– Murakami Kijo"},
+	}
+
+	for _, seed := range seeds {
+		if _, err := snippets.Insert(seed.title, seed.content, 365); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	logger.Info("seeded database", "snippets", len(seeds))
+}
+
+// purgeCmd runs one purge pass from the terminal, using the same cutoff
+// the background job applies.
+func purgeCmd(args []string) {
+	cfg, _ := parseConfig(args, nil)
+
+	logger, _, err := newLogger(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	cutoff := time.Now().UTC().Add(-cfg.Purge.Retention)
+
+	snippets, sessions, err := purgeExpired(db, cfg.DB.Driver, cutoff)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	logger.Info("purge complete", "snippets", snippets, "sessions", sessions)
+}
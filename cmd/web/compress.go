@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// compressMinSize is the smallest body worth compressing: below this the
+// gzip header overhead and CPU cost outweigh the saved bytes.
+const compressMinSize = 1024
+
+// compressibleTypes lists the content types that compress well. Images
+// and other already-compressed formats are deliberately absent.
+var compressibleTypes = map[string]bool{
+	"text/html":              true,
+	"text/css":               true,
+	"text/plain":             true,
+	"application/json":       true,
+	"application/javascript": true,
+	"text/javascript":        true,
+	"image/svg+xml":          true,
+}
+
+// gzipPool recycles gzip writers across requests.
+var gzipPool = sync.Pool{
+	New: func() any {
+		return gzip.NewWriter(io.Discard)
+	},
+}
+
+// compress gzips responses for clients that accept it, skipping small
+// bodies and content types that won't benefit. Responses are buffered
+// until they pass compressMinSize, so the decision is made per response
+// without handlers knowing about it.
+func compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// The response differs by Accept-Encoding from here on, whether
+		// or not this particular body ends up compressed.
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		cw := &compressResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		defer cw.close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressResponseWriter buffers the response until it can tell whether
+// compressing is worthwhile, then streams either plain or gzipped output.
+type compressResponseWriter struct {
+	http.ResponseWriter
+
+	status      int
+	buf         []byte
+	gz          *gzip.Writer
+	passThrough bool
+	wroteHeader bool
+}
+
+func (cw *compressResponseWriter) WriteHeader(status int) {
+	cw.status = status
+}
+
+func (cw *compressResponseWriter) Write(b []byte) (int, error) {
+	if cw.passThrough {
+		return cw.ResponseWriter.Write(b)
+	}
+	if cw.gz != nil {
+		return cw.gz.Write(b)
+	}
+
+	cw.buf = append(cw.buf, b...)
+
+	if !cw.compressible() {
+		cw.startPlain()
+		return len(b), nil
+	}
+
+	if len(cw.buf) >= compressMinSize {
+		if err := cw.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+// compressible reports whether the response, as the handler has shaped
+// it so far, is a candidate for compression. Handlers that rely on
+// net/http's content sniffing never set Content-Type themselves, so the
+// buffered body is sniffed the same way here.
+func (cw *compressResponseWriter) compressible() bool {
+	h := cw.Header()
+
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	ct := h.Get("Content-Type")
+	if ct == "" && len(cw.buf) > 0 {
+		ct = http.DetectContentType(cw.buf)
+		h.Set("Content-Type", ct)
+	}
+	if i := strings.Index(ct, ";"); i >= 0 {
+		ct = ct[:i]
+	}
+
+	return compressibleTypes[strings.TrimSpace(ct)]
+}
+
+// startPlain abandons compression and forwards everything buffered.
+func (cw *compressResponseWriter) startPlain() {
+	cw.passThrough = true
+	cw.writeHeader()
+	if len(cw.buf) > 0 {
+		cw.ResponseWriter.Write(cw.buf)
+		cw.buf = nil
+	}
+}
+
+// startGzip commits to compressing: the Content-Length no longer applies
+// and everything buffered is replayed through the gzip writer.
+func (cw *compressResponseWriter) startGzip() error {
+	h := cw.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Encoding", "gzip")
+
+	cw.writeHeader()
+
+	cw.gz = gzipPool.Get().(*gzip.Writer)
+	cw.gz.Reset(cw.ResponseWriter)
+
+	_, err := cw.gz.Write(cw.buf)
+	cw.buf = nil
+
+	return err
+}
+
+func (cw *compressResponseWriter) writeHeader() {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		cw.ResponseWriter.WriteHeader(cw.status)
+	}
+}
+
+// close flushes whatever state the response ended in. Short compressible
+// bodies that never reached compressMinSize go out uncompressed.
+func (cw *compressResponseWriter) close() {
+	if cw.gz != nil {
+		cw.gz.Close()
+		gzipPool.Put(cw.gz)
+		cw.gz = nil
+		return
+	}
+	if !cw.passThrough {
+		cw.startPlain()
+	}
+}
+
+// Flush forces a decision and passes the flush on, so streaming
+// responses keep working behind the middleware.
+func (cw *compressResponseWriter) Flush() {
+	if cw.gz != nil {
+		cw.gz.Flush()
+	} else if !cw.passThrough {
+		cw.startPlain()
+	}
+
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack passes through to the underlying writer for WebSocket upgrades.
+func (cw *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		cw.passThrough = true
+		return hj.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Unwrap exposes the underlying writer, so http.ResponseController can
+// reach per-request controls like write deadlines through the wrapper.
+func (cw *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
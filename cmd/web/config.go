@@ -0,0 +1,1059 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config holds every runtime setting for the application, grouped by
+// concern. Values are resolved with the precedence: command-line flags,
+// then the config file, then the built-in defaults.
+type config struct {
+	Server   serverConfig   `yaml:"server"`
+	DB       dbConfig       `yaml:"db"`
+	Session  sessionConfig  `yaml:"session"`
+	Purge    purgeConfig    `yaml:"purge"`
+	Limit    limitConfig    `yaml:"limit"`
+	Access   accessConfig   `yaml:"access"`
+	Tasks    tasksConfig    `yaml:"tasks"`
+	Mail     mailConfig     `yaml:"mail"`
+	SMTP     smtpConfig     `yaml:"smtp"`
+	Contact  contactConfig  `yaml:"contact"`
+	Security securityConfig `yaml:"security"`
+	Log      logConfig      `yaml:"log"`
+	Sentry   sentryConfig   `yaml:"sentry"`
+}
+
+type serverConfig struct {
+	Addr              string        `yaml:"addr"`
+	TLS               bool          `yaml:"tls"`
+	TLSCert           string        `yaml:"tls_cert"`
+	TLSKey            string        `yaml:"tls_key"`
+	Autocert          bool          `yaml:"autocert"`
+	AutocertHosts     string        `yaml:"autocert_hosts"`
+	AutocertCache     string        `yaml:"autocert_cache"`
+	HandlerTimeout    time.Duration `yaml:"handler_timeout"`
+	TLSMinVersion     string        `yaml:"tls_min_version"`
+	TLSCurves         string        `yaml:"tls_curves"`
+	TLSCiphers        string        `yaml:"tls_ciphers"`
+	TrustedProxies    string        `yaml:"trusted_proxies"`
+	BasePath          string        `yaml:"base_path"`
+	AssetURL          string        `yaml:"asset_url"`
+	BaseURL           string        `yaml:"base_url"`
+	Timezone          string        `yaml:"timezone"`
+	CSP               string        `yaml:"csp"`
+	HSTSMaxAge        time.Duration `yaml:"hsts_max_age"`
+	HSTSSubdomains    bool          `yaml:"hsts_subdomains"`
+	HSTSPreload       bool          `yaml:"hsts_preload"`
+	ReferrerPolicy    string        `yaml:"referrer_policy"`
+	PermissionsPolicy string        `yaml:"permissions_policy"`
+	FrameOptions      string        `yaml:"frame_options"`
+	Debug             bool          `yaml:"debug"`
+}
+
+type dbConfig struct {
+	Driver             string        `yaml:"driver"`
+	DSN                string        `yaml:"dsn"`
+	ReadDSN            string        `yaml:"read_dsn"`
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+	MaxOpenConns       int           `yaml:"max_open_conns"`
+	MaxIdleConns       int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime    time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime    time.Duration `yaml:"conn_max_idle_time"`
+	TLSCA              string        `yaml:"tls_ca"`
+	TLSCert            string        `yaml:"tls_cert"`
+	TLSKey             string        `yaml:"tls_key"`
+	TLSServerName      string        `yaml:"tls_server_name"`
+}
+
+type sessionConfig struct {
+	Lifetime      time.Duration `yaml:"lifetime"`
+	Store         string        `yaml:"store"`
+	CookieName    string        `yaml:"cookie_name"`
+	CookieDomain  string        `yaml:"cookie_domain"`
+	CookiePath    string        `yaml:"cookie_path"`
+	SameSite      string        `yaml:"same_site"`
+	Keys          string        `yaml:"keys"`
+	RedisAddr     string        `yaml:"redis_addr"`
+	RedisPassword string        `yaml:"redis_password"`
+	RedisTLS      bool          `yaml:"redis_tls"`
+}
+
+// purgeConfig controls the background job that hard-deletes expired
+// snippets and stale session rows.
+type purgeConfig struct {
+	Interval  time.Duration `yaml:"interval"`
+	Retention time.Duration `yaml:"retention"`
+}
+
+// accessConfig holds the initial IP allow and block lists; admins can
+// edit them at runtime.
+type accessConfig struct {
+	Allow string `yaml:"allow"`
+	Block string `yaml:"block"`
+}
+
+// limitConfig controls the per-IP request rate limiter. An RPS of zero
+// leaves the limiter disabled.
+type limitConfig struct {
+	RPS       float64 `yaml:"rps"`
+	Burst     int     `yaml:"burst"`
+	PostRPS   float64 `yaml:"post_rps"`
+	PostBurst int     `yaml:"post_burst"`
+	Exempt    string  `yaml:"exempt"`
+}
+
+// tasksConfig controls the asynchronous task queue workers.
+type tasksConfig struct {
+	Workers int `yaml:"workers"`
+}
+
+// mailConfig selects the outgoing email provider and holds the settings
+// shared by all of them. The default "smtp" provider reads its server
+// details from the smtp section; "ses", "sendgrid" and "mailgun" call
+// the provider's HTTP API with the credentials here. Email features
+// stay disabled until the selected provider has its credentials.
+type mailConfig struct {
+	Provider  string `yaml:"provider"`
+	Sender    string `yaml:"sender"`
+	APIKey    string `yaml:"api_key"`
+	Domain    string `yaml:"domain"`
+	Region    string `yaml:"region"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+}
+
+// smtpConfig points the smtp mail provider at its server. TLS selects
+// an implicit-TLS connection (typically port 465); otherwise STARTTLS
+// is used when the server offers it.
+type smtpConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	TLS      bool   `yaml:"tls"`
+}
+
+// contactConfig controls the /contact form. Messages are always stored
+// for the admin dashboard; Email is where they are additionally
+// delivered once a mailer is configured.
+type contactConfig struct {
+	Email string `yaml:"email"`
+}
+
+// securityConfig drives the /.well-known/security.txt endpoint, which is
+// only served when a contact is configured.
+type securityConfig struct {
+	Contact string        `yaml:"contact"`
+	Policy  string        `yaml:"policy"`
+	Expiry  time.Duration `yaml:"expiry"`
+}
+
+type logConfig struct {
+	Format    string `yaml:"format"`
+	Level     string `yaml:"level"`
+	Output    string `yaml:"output"`
+	AccessLog string `yaml:"access_log"`
+}
+
+type sentryConfig struct {
+	DSN string `yaml:"dsn"`
+}
+
+// defaultConfig returns the built-in defaults, matching the flag defaults
+// the application has always shipped with.
+func defaultConfig() config {
+	var cfg config
+
+	cfg.Server.Addr = ":4000"
+	cfg.Server.TLS = true
+	cfg.Server.TLSCert = "./tls/cert.pem"
+	cfg.Server.TLSKey = "./tls/key.pem"
+	cfg.Server.AutocertCache = "./tls/autocert"
+	cfg.Server.HandlerTimeout = 5 * time.Second
+	cfg.Server.CSP = "default-src 'self'; style-src 'self' fonts.googleapis.com; font-src fonts.gstatic.com"
+	cfg.Server.ReferrerPolicy = "origin-when-cross-origin"
+	cfg.Server.FrameOptions = "deny"
+	cfg.Server.TLSMinVersion = "1.2"
+	cfg.Server.TLSCurves = "X25519,P256"
+	cfg.Server.Timezone = "UTC"
+	cfg.DB.Driver = "mysql"
+	cfg.DB.DSN = "web:pass@/snippetbox?parseTime=true"
+	cfg.DB.SlowQueryThreshold = 200 * time.Millisecond
+	cfg.DB.MaxOpenConns = 25
+	cfg.DB.MaxIdleConns = 25
+	cfg.DB.ConnMaxLifetime = time.Hour
+	cfg.DB.ConnMaxIdleTime = 5 * time.Minute
+	cfg.Session.Lifetime = 12 * time.Hour
+	cfg.Session.Store = "db"
+	cfg.Session.CookieName = "session"
+	cfg.Session.CookiePath = "/"
+	cfg.Session.SameSite = "lax"
+	cfg.Limit.Burst = 20
+	cfg.Limit.PostBurst = 5
+	cfg.Purge.Interval = time.Hour
+	cfg.Purge.Retention = 7 * 24 * time.Hour
+	cfg.Tasks.Workers = 4
+	cfg.Mail.Provider = "smtp"
+	cfg.SMTP.Port = 25
+	cfg.Security.Expiry = 30 * 24 * time.Hour
+	cfg.Log.Format = "text"
+	cfg.Log.Level = "info"
+	cfg.Log.Output = "stdout"
+
+	return cfg
+}
+
+// parseConfig resolves the full configuration from defaults, an optional
+// YAML config file, SNIPPETBOX_* environment variables and command-line
+// flags (highest precedence), validating the result. It terminates the
+// process with a usage message on bad input, like flag.Parse does. The
+// second return value holds the positional arguments left after the
+// flags, for subcommands that take them. A non-nil extra callback can
+// register command-specific flags on the shared flag set.
+func parseConfig(args []string, extra func(fs *flag.FlagSet)) (config, []string) {
+	cfg := defaultConfig()
+
+	fs := flag.NewFlagSet("snippetbox", flag.ExitOnError)
+
+	if extra != nil {
+		extra(fs)
+	}
+
+	configPath := fs.String("config", "", "Path to a YAML config file (optional)")
+
+	addr := newAddrList(cfg.Server.Addr)
+	fs.Var(addr, "addr", "HTTP network address (repeat for extra internal-only listeners)")
+	tlsFlag := fs.Bool("tls", cfg.Server.TLS, "Serve HTTPS (disable when TLS terminates at a reverse proxy)")
+	tlsCert := fs.String("tls-cert", cfg.Server.TLSCert, "Path to the TLS certificate")
+	tlsKey := fs.String("tls-key", cfg.Server.TLSKey, "Path to the TLS private key")
+	autocertFlag := fs.Bool("autocert", cfg.Server.Autocert, "Obtain TLS certificates automatically from Let's Encrypt")
+	autocertHosts := fs.String("autocert-hosts", cfg.Server.AutocertHosts, "Comma-separated hostnames allowed for automatic certificates")
+	handlerTimeout := fs.Duration("handler-timeout", cfg.Server.HandlerTimeout, "Cancel database-heavy handlers after this long (0 disables)")
+	tlsMinVersion := fs.String("tls-min-version", cfg.Server.TLSMinVersion, "Minimum TLS version to accept (1.2 or 1.3)")
+	tlsCurves := fs.String("tls-curves", cfg.Server.TLSCurves, "Comma-separated TLS curve preferences (X25519, P256, P384, P521)")
+	tlsCiphers := fs.String("tls-ciphers", cfg.Server.TLSCiphers, "Comma-separated TLS 1.2 cipher suite names (empty for the Go defaults)")
+	autocertCache := fs.String("autocert-cache", cfg.Server.AutocertCache, "Directory to cache automatic certificates in")
+	csp := fs.String("csp", cfg.Server.CSP, "Content-Security-Policy header; $NONCE is replaced by a per-request nonce (empty disables)")
+	hstsMaxAge := fs.Duration("hsts-max-age", cfg.Server.HSTSMaxAge, "Strict-Transport-Security max-age (0 disables HSTS)")
+	hstsSubdomains := fs.Bool("hsts-subdomains", cfg.Server.HSTSSubdomains, "Add includeSubDomains to the HSTS header")
+	hstsPreload := fs.Bool("hsts-preload", cfg.Server.HSTSPreload, "Add preload to the HSTS header")
+	referrerPolicy := fs.String("referrer-policy", cfg.Server.ReferrerPolicy, "Referrer-Policy header (empty disables)")
+	permissionsPolicy := fs.String("permissions-policy", cfg.Server.PermissionsPolicy, "Permissions-Policy header (empty disables)")
+	frameOptions := fs.String("frame-options", cfg.Server.FrameOptions, "X-Frame-Options header: deny, sameorigin or empty to disable")
+	assetURL := fs.String("asset-url", cfg.Server.AssetURL, "Base URL to serve static assets from, e.g. a CDN (optional)")
+	baseURL := fs.String("base-url", cfg.Server.BaseURL, "Public scheme and host of the site, e.g. https://snippetbox.example.com, used for absolute URLs (optional)")
+	timezone := fs.String("timezone", cfg.Server.Timezone, "IANA timezone dates are rendered in unless the visitor picks another")
+	basePath := fs.String("base-path", cfg.Server.BasePath, "URL prefix to serve the application under (e.g. /snippets)")
+	trustedProxies := fs.String("trusted-proxies", cfg.Server.TrustedProxies, "Comma-separated CIDRs of proxies whose X-Forwarded-* headers are trusted")
+	debug := fs.Bool("debug", cfg.Server.Debug, "Enable debug features (pprof endpoints, stack traces in error responses)")
+	dbDriver := fs.String("db-driver", cfg.DB.Driver, "Database driver (mysql or sqlite3)")
+	dsn := fs.String("dsn", cfg.DB.DSN, "Database data source name")
+	dsnFile := fs.String("dsn-file", "", "File to read the MySQL data source name from (overrides -dsn)")
+	readDSN := fs.String("read-dsn", cfg.DB.ReadDSN, "Data source name of a read replica (optional)")
+	readDSNFile := fs.String("read-dsn-file", "", "File to read the replica data source name from (overrides -read-dsn)")
+	slowQueryThreshold := fs.Duration("slow-query-threshold", cfg.DB.SlowQueryThreshold, "Log queries slower than this (0 disables)")
+	dbMaxOpenConns := fs.Int("db-max-open-conns", cfg.DB.MaxOpenConns, "Maximum open database connections (0 for unlimited)")
+	dbMaxIdleConns := fs.Int("db-max-idle-conns", cfg.DB.MaxIdleConns, "Maximum idle database connections")
+	dbConnMaxLifetime := fs.Duration("db-conn-max-lifetime", cfg.DB.ConnMaxLifetime, "Maximum database connection lifetime (0 for unlimited)")
+	dbConnMaxIdleTime := fs.Duration("db-conn-max-idle-time", cfg.DB.ConnMaxIdleTime, "Maximum database connection idle time (0 for unlimited)")
+	dbTLSCA := fs.String("db-tls-ca", cfg.DB.TLSCA, "Path to a CA certificate for TLS connections to MySQL (enables TLS)")
+	dbTLSCert := fs.String("db-tls-cert", cfg.DB.TLSCert, "Path to a client certificate for TLS connections to MySQL (optional)")
+	dbTLSKey := fs.String("db-tls-key", cfg.DB.TLSKey, "Path to the client certificate key (optional)")
+	dbTLSServerName := fs.String("db-tls-server-name", cfg.DB.TLSServerName, "Server name to verify the MySQL certificate against (defaults to the DSN host)")
+	sessionLifetime := fs.Duration("session-lifetime", cfg.Session.Lifetime, "Session lifetime")
+	sessionStore := fs.String("session-store", cfg.Session.Store, "Session store backend (db, redis, memory or cookie)")
+	sessionCookieName := fs.String("session-cookie-name", cfg.Session.CookieName, "Name of the session cookie")
+	sessionCookieDomain := fs.String("session-cookie-domain", cfg.Session.CookieDomain, "Domain attribute of the session cookie (empty for host-only)")
+	sessionCookiePath := fs.String("session-cookie-path", cfg.Session.CookiePath, "Path attribute of the session cookie")
+	sessionSameSite := fs.String("session-same-site", cfg.Session.SameSite, "SameSite mode of the session cookie (lax, strict or none)")
+	sessionKeys := fs.String("session-keys", cfg.Session.Keys, "Comma-separated hex keys for the cookie session store, newest first")
+	sessionKeysFile := fs.String("session-keys-file", "", "File to read the cookie session keys from (overrides -session-keys)")
+	sessionRedisAddr := fs.String("session-redis-addr", cfg.Session.RedisAddr, "Redis address for the redis session store (host:port)")
+	sessionRedisPassword := fs.String("session-redis-password", cfg.Session.RedisPassword, "Redis password (optional)")
+	sessionRedisPasswordFile := fs.String("session-redis-password-file", "", "File to read the Redis password from (overrides -session-redis-password)")
+	sessionRedisTLS := fs.Bool("session-redis-tls", cfg.Session.RedisTLS, "Connect to Redis over TLS")
+	accessAllow := fs.String("access-allow", cfg.Access.Allow, "Comma-separated CIDRs exclusively allowed to connect (empty allows everyone)")
+	accessBlock := fs.String("access-block", cfg.Access.Block, "Comma-separated CIDRs blocked from connecting")
+	limitRPS := fs.Float64("limit-rps", cfg.Limit.RPS, "Per-IP sustained requests per second (0 disables rate limiting)")
+	limitBurst := fs.Int("limit-burst", cfg.Limit.Burst, "Per-IP request burst size")
+	limitPostRPS := fs.Float64("limit-post-rps", cfg.Limit.PostRPS, "Stricter per-IP limit for state-changing requests (0 to share the general limit)")
+	limitPostBurst := fs.Int("limit-post-burst", cfg.Limit.PostBurst, "Burst size for the state-changing limit")
+	limitExempt := fs.String("limit-exempt", cfg.Limit.Exempt, "Comma-separated CIDRs exempt from rate limiting")
+	purgeInterval := fs.Duration("purge-interval", cfg.Purge.Interval, "How often to purge expired rows (0 disables the background purge)")
+	purgeRetention := fs.Duration("purge-retention", cfg.Purge.Retention, "How long expired snippets are kept before being purged")
+	taskWorkers := fs.Int("task-workers", cfg.Tasks.Workers, "Number of background task queue workers (0 disables the queue)")
+	mailProvider := fs.String("mail-provider", cfg.Mail.Provider, "Outgoing email provider (smtp, ses, sendgrid or mailgun)")
+	mailSender := fs.String("mail-sender", cfg.Mail.Sender, "Sender address for outgoing email, e.g. Snippetbox <no-reply@example.com>")
+	mailAPIKey := fs.String("mail-api-key", cfg.Mail.APIKey, "API key for the sendgrid and mailgun providers")
+	mailAPIKeyFile := fs.String("mail-api-key-file", "", "File to read the mail API key from (overrides -mail-api-key)")
+	mailDomain := fs.String("mail-domain", cfg.Mail.Domain, "Sending domain for the mailgun provider")
+	mailRegion := fs.String("mail-region", cfg.Mail.Region, "AWS region for the ses provider, e.g. eu-west-1")
+	mailAccessKey := fs.String("mail-access-key", cfg.Mail.AccessKey, "AWS access key ID for the ses provider")
+	mailSecretKey := fs.String("mail-secret-key", cfg.Mail.SecretKey, "AWS secret access key for the ses provider")
+	mailSecretKeyFile := fs.String("mail-secret-key-file", "", "File to read the AWS secret access key from (overrides -mail-secret-key)")
+	smtpHost := fs.String("smtp-host", cfg.SMTP.Host, "SMTP server hostname for the smtp provider (empty disables email)")
+	smtpPort := fs.Int("smtp-port", cfg.SMTP.Port, "SMTP server port")
+	smtpUsername := fs.String("smtp-username", cfg.SMTP.Username, "SMTP username (empty for unauthenticated servers)")
+	smtpPassword := fs.String("smtp-password", cfg.SMTP.Password, "SMTP password")
+	smtpPasswordFile := fs.String("smtp-password-file", "", "File to read the SMTP password from (overrides -smtp-password)")
+	smtpTLS := fs.Bool("smtp-tls", cfg.SMTP.TLS, "Connect to the SMTP server over implicit TLS instead of STARTTLS")
+	contactEmail := fs.String("contact-email", cfg.Contact.Email, "Address contact form messages are delivered to (optional)")
+	securityContact := fs.String("security-contact", cfg.Security.Contact, "Contact for security.txt, e.g. mailto:security@example.com (empty disables the endpoint)")
+	securityPolicy := fs.String("security-policy", cfg.Security.Policy, "Policy URL for security.txt (optional)")
+	securityExpiry := fs.Duration("security-expiry", cfg.Security.Expiry, "How far ahead the security.txt Expires field is set")
+	logFormat := fs.String("log-format", cfg.Log.Format, "Log format (text or json)")
+	logLevel := fs.String("log-level", cfg.Log.Level, "Minimum log level (debug, info, warn or error)")
+	logOutput := fs.String("log-output", cfg.Log.Output, "Log destination (stdout or syslog)")
+	accessLog := fs.String("access-log", cfg.Log.AccessLog, "File to append an Apache combined format access log to (optional)")
+	sentryDSN := fs.String("sentry-dsn", cfg.Sentry.DSN, "Sentry DSN for error reporting (optional)")
+	sentryDSNFile := fs.String("sentry-dsn-file", "", "File to read the Sentry DSN from (overrides -sentry-dsn)")
+
+	fs.Parse(args)
+
+	if *configPath != "" {
+		if err := loadConfigFile(*configPath, &cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Flags given explicitly on the command line override both the
+	// environment and the file.
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "dsn-file":
+			cfg.DB.DSN, flagErr = readSecretFile(*dsnFile)
+		case "sentry-dsn-file":
+			cfg.Sentry.DSN, flagErr = readSecretFile(*sentryDSNFile)
+		case "addr":
+			cfg.Server.Addr = addr.value
+		case "tls":
+			cfg.Server.TLS = *tlsFlag
+		case "tls-cert":
+			cfg.Server.TLSCert = *tlsCert
+		case "tls-key":
+			cfg.Server.TLSKey = *tlsKey
+		case "debug":
+			cfg.Server.Debug = *debug
+		case "autocert":
+			cfg.Server.Autocert = *autocertFlag
+		case "autocert-hosts":
+			cfg.Server.AutocertHosts = *autocertHosts
+		case "autocert-cache":
+			cfg.Server.AutocertCache = *autocertCache
+		case "handler-timeout":
+			cfg.Server.HandlerTimeout = *handlerTimeout
+		case "tls-min-version":
+			cfg.Server.TLSMinVersion = *tlsMinVersion
+		case "tls-curves":
+			cfg.Server.TLSCurves = *tlsCurves
+		case "tls-ciphers":
+			cfg.Server.TLSCiphers = *tlsCiphers
+		case "trusted-proxies":
+			cfg.Server.TrustedProxies = *trustedProxies
+		case "base-path":
+			cfg.Server.BasePath = *basePath
+		case "asset-url":
+			cfg.Server.AssetURL = *assetURL
+		case "base-url":
+			cfg.Server.BaseURL = *baseURL
+		case "timezone":
+			cfg.Server.Timezone = *timezone
+		case "csp":
+			cfg.Server.CSP = *csp
+		case "hsts-max-age":
+			cfg.Server.HSTSMaxAge = *hstsMaxAge
+		case "hsts-subdomains":
+			cfg.Server.HSTSSubdomains = *hstsSubdomains
+		case "hsts-preload":
+			cfg.Server.HSTSPreload = *hstsPreload
+		case "referrer-policy":
+			cfg.Server.ReferrerPolicy = *referrerPolicy
+		case "permissions-policy":
+			cfg.Server.PermissionsPolicy = *permissionsPolicy
+		case "frame-options":
+			cfg.Server.FrameOptions = *frameOptions
+		case "db-driver":
+			cfg.DB.Driver = *dbDriver
+		case "dsn":
+			cfg.DB.DSN = *dsn
+		case "read-dsn":
+			cfg.DB.ReadDSN = *readDSN
+		case "read-dsn-file":
+			cfg.DB.ReadDSN, flagErr = readSecretFile(*readDSNFile)
+		case "slow-query-threshold":
+			cfg.DB.SlowQueryThreshold = *slowQueryThreshold
+		case "db-max-open-conns":
+			cfg.DB.MaxOpenConns = *dbMaxOpenConns
+		case "db-max-idle-conns":
+			cfg.DB.MaxIdleConns = *dbMaxIdleConns
+		case "db-conn-max-lifetime":
+			cfg.DB.ConnMaxLifetime = *dbConnMaxLifetime
+		case "db-conn-max-idle-time":
+			cfg.DB.ConnMaxIdleTime = *dbConnMaxIdleTime
+		case "db-tls-ca":
+			cfg.DB.TLSCA = *dbTLSCA
+		case "db-tls-cert":
+			cfg.DB.TLSCert = *dbTLSCert
+		case "db-tls-key":
+			cfg.DB.TLSKey = *dbTLSKey
+		case "db-tls-server-name":
+			cfg.DB.TLSServerName = *dbTLSServerName
+		case "session-lifetime":
+			cfg.Session.Lifetime = *sessionLifetime
+		case "session-store":
+			cfg.Session.Store = *sessionStore
+		case "session-cookie-name":
+			cfg.Session.CookieName = *sessionCookieName
+		case "session-cookie-domain":
+			cfg.Session.CookieDomain = *sessionCookieDomain
+		case "session-cookie-path":
+			cfg.Session.CookiePath = *sessionCookiePath
+		case "session-same-site":
+			cfg.Session.SameSite = *sessionSameSite
+		case "session-keys":
+			cfg.Session.Keys = *sessionKeys
+		case "session-keys-file":
+			cfg.Session.Keys, flagErr = readSecretFile(*sessionKeysFile)
+		case "session-redis-addr":
+			cfg.Session.RedisAddr = *sessionRedisAddr
+		case "session-redis-password":
+			cfg.Session.RedisPassword = *sessionRedisPassword
+		case "session-redis-password-file":
+			cfg.Session.RedisPassword, flagErr = readSecretFile(*sessionRedisPasswordFile)
+		case "session-redis-tls":
+			cfg.Session.RedisTLS = *sessionRedisTLS
+		case "access-allow":
+			cfg.Access.Allow = *accessAllow
+		case "access-block":
+			cfg.Access.Block = *accessBlock
+		case "limit-rps":
+			cfg.Limit.RPS = *limitRPS
+		case "limit-burst":
+			cfg.Limit.Burst = *limitBurst
+		case "limit-post-rps":
+			cfg.Limit.PostRPS = *limitPostRPS
+		case "limit-post-burst":
+			cfg.Limit.PostBurst = *limitPostBurst
+		case "limit-exempt":
+			cfg.Limit.Exempt = *limitExempt
+		case "purge-interval":
+			cfg.Purge.Interval = *purgeInterval
+		case "purge-retention":
+			cfg.Purge.Retention = *purgeRetention
+		case "task-workers":
+			cfg.Tasks.Workers = *taskWorkers
+		case "mail-provider":
+			cfg.Mail.Provider = *mailProvider
+		case "mail-sender":
+			cfg.Mail.Sender = *mailSender
+		case "mail-api-key":
+			cfg.Mail.APIKey = *mailAPIKey
+		case "mail-api-key-file":
+			cfg.Mail.APIKey, flagErr = readSecretFile(*mailAPIKeyFile)
+		case "mail-domain":
+			cfg.Mail.Domain = *mailDomain
+		case "mail-region":
+			cfg.Mail.Region = *mailRegion
+		case "mail-access-key":
+			cfg.Mail.AccessKey = *mailAccessKey
+		case "mail-secret-key":
+			cfg.Mail.SecretKey = *mailSecretKey
+		case "mail-secret-key-file":
+			cfg.Mail.SecretKey, flagErr = readSecretFile(*mailSecretKeyFile)
+		case "smtp-host":
+			cfg.SMTP.Host = *smtpHost
+		case "smtp-port":
+			cfg.SMTP.Port = *smtpPort
+		case "smtp-username":
+			cfg.SMTP.Username = *smtpUsername
+		case "smtp-password":
+			cfg.SMTP.Password = *smtpPassword
+		case "smtp-password-file":
+			cfg.SMTP.Password, flagErr = readSecretFile(*smtpPasswordFile)
+		case "smtp-tls":
+			cfg.SMTP.TLS = *smtpTLS
+		case "contact-email":
+			cfg.Contact.Email = *contactEmail
+		case "security-contact":
+			cfg.Security.Contact = *securityContact
+		case "security-policy":
+			cfg.Security.Policy = *securityPolicy
+		case "security-expiry":
+			cfg.Security.Expiry = *securityExpiry
+		case "log-format":
+			cfg.Log.Format = *logFormat
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		case "log-output":
+			cfg.Log.Output = *logOutput
+		case "access-log":
+			cfg.Log.AccessLog = *accessLog
+		case "sentry-dsn":
+			cfg.Sentry.DSN = *sentryDSN
+		}
+	})
+
+	if flagErr != nil {
+		fmt.Fprintln(os.Stderr, flagErr)
+		os.Exit(1)
+	}
+
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	return cfg, fs.Args()
+}
+
+// applyEnv overlays SNIPPETBOX_* environment variables onto cfg, so every
+// setting can be provided in containerized deployments without wrapper
+// scripts.
+func applyEnv(cfg *config) error {
+	vars := []struct {
+		name string
+		set  func(string) error
+	}{
+		{"SNIPPETBOX_ADDR", setString(&cfg.Server.Addr)},
+		{"SNIPPETBOX_TLS", setBool(&cfg.Server.TLS)},
+		{"SNIPPETBOX_TLS_CERT", setString(&cfg.Server.TLSCert)},
+		{"SNIPPETBOX_TLS_KEY", setString(&cfg.Server.TLSKey)},
+		{"SNIPPETBOX_DEBUG", setBool(&cfg.Server.Debug)},
+		{"SNIPPETBOX_AUTOCERT", setBool(&cfg.Server.Autocert)},
+		{"SNIPPETBOX_AUTOCERT_HOSTS", setString(&cfg.Server.AutocertHosts)},
+		{"SNIPPETBOX_AUTOCERT_CACHE", setString(&cfg.Server.AutocertCache)},
+		{"SNIPPETBOX_HANDLER_TIMEOUT", setDuration(&cfg.Server.HandlerTimeout)},
+		{"SNIPPETBOX_TLS_MIN_VERSION", setString(&cfg.Server.TLSMinVersion)},
+		{"SNIPPETBOX_TLS_CURVES", setString(&cfg.Server.TLSCurves)},
+		{"SNIPPETBOX_TLS_CIPHERS", setString(&cfg.Server.TLSCiphers)},
+		{"SNIPPETBOX_TRUSTED_PROXIES", setString(&cfg.Server.TrustedProxies)},
+		{"SNIPPETBOX_BASE_PATH", setString(&cfg.Server.BasePath)},
+		{"SNIPPETBOX_ASSET_URL", setString(&cfg.Server.AssetURL)},
+		{"SNIPPETBOX_BASE_URL", setString(&cfg.Server.BaseURL)},
+		{"SNIPPETBOX_TIMEZONE", setString(&cfg.Server.Timezone)},
+		{"SNIPPETBOX_CSP", setString(&cfg.Server.CSP)},
+		{"SNIPPETBOX_HSTS_MAX_AGE", setDuration(&cfg.Server.HSTSMaxAge)},
+		{"SNIPPETBOX_HSTS_SUBDOMAINS", setBool(&cfg.Server.HSTSSubdomains)},
+		{"SNIPPETBOX_HSTS_PRELOAD", setBool(&cfg.Server.HSTSPreload)},
+		{"SNIPPETBOX_REFERRER_POLICY", setString(&cfg.Server.ReferrerPolicy)},
+		{"SNIPPETBOX_PERMISSIONS_POLICY", setString(&cfg.Server.PermissionsPolicy)},
+		{"SNIPPETBOX_FRAME_OPTIONS", setString(&cfg.Server.FrameOptions)},
+		{"SNIPPETBOX_DB_DRIVER", setString(&cfg.DB.Driver)},
+		{"SNIPPETBOX_DSN", setString(&cfg.DB.DSN)},
+		{"SNIPPETBOX_DSN_FILE", setSecretFile(&cfg.DB.DSN)},
+		{"SNIPPETBOX_READ_DSN", setString(&cfg.DB.ReadDSN)},
+		{"SNIPPETBOX_READ_DSN_FILE", setSecretFile(&cfg.DB.ReadDSN)},
+		{"SNIPPETBOX_SLOW_QUERY_THRESHOLD", setDuration(&cfg.DB.SlowQueryThreshold)},
+		{"SNIPPETBOX_DB_MAX_OPEN_CONNS", setInt(&cfg.DB.MaxOpenConns)},
+		{"SNIPPETBOX_DB_MAX_IDLE_CONNS", setInt(&cfg.DB.MaxIdleConns)},
+		{"SNIPPETBOX_DB_CONN_MAX_LIFETIME", setDuration(&cfg.DB.ConnMaxLifetime)},
+		{"SNIPPETBOX_DB_CONN_MAX_IDLE_TIME", setDuration(&cfg.DB.ConnMaxIdleTime)},
+		{"SNIPPETBOX_DB_TLS_CA", setString(&cfg.DB.TLSCA)},
+		{"SNIPPETBOX_DB_TLS_CERT", setString(&cfg.DB.TLSCert)},
+		{"SNIPPETBOX_DB_TLS_KEY", setString(&cfg.DB.TLSKey)},
+		{"SNIPPETBOX_DB_TLS_SERVER_NAME", setString(&cfg.DB.TLSServerName)},
+		{"SNIPPETBOX_SESSION_LIFETIME", setDuration(&cfg.Session.Lifetime)},
+		{"SNIPPETBOX_SESSION_STORE", setString(&cfg.Session.Store)},
+		{"SNIPPETBOX_SESSION_COOKIE_NAME", setString(&cfg.Session.CookieName)},
+		{"SNIPPETBOX_SESSION_COOKIE_DOMAIN", setString(&cfg.Session.CookieDomain)},
+		{"SNIPPETBOX_SESSION_COOKIE_PATH", setString(&cfg.Session.CookiePath)},
+		{"SNIPPETBOX_SESSION_SAME_SITE", setString(&cfg.Session.SameSite)},
+		{"SNIPPETBOX_SESSION_KEYS", setString(&cfg.Session.Keys)},
+		{"SNIPPETBOX_SESSION_KEYS_FILE", setSecretFile(&cfg.Session.Keys)},
+		{"SNIPPETBOX_SESSION_REDIS_ADDR", setString(&cfg.Session.RedisAddr)},
+		{"SNIPPETBOX_SESSION_REDIS_PASSWORD", setString(&cfg.Session.RedisPassword)},
+		{"SNIPPETBOX_SESSION_REDIS_PASSWORD_FILE", setSecretFile(&cfg.Session.RedisPassword)},
+		{"SNIPPETBOX_SESSION_REDIS_TLS", setBool(&cfg.Session.RedisTLS)},
+		{"SNIPPETBOX_ACCESS_ALLOW", setString(&cfg.Access.Allow)},
+		{"SNIPPETBOX_ACCESS_BLOCK", setString(&cfg.Access.Block)},
+		{"SNIPPETBOX_LIMIT_RPS", setFloat(&cfg.Limit.RPS)},
+		{"SNIPPETBOX_LIMIT_BURST", setInt(&cfg.Limit.Burst)},
+		{"SNIPPETBOX_LIMIT_POST_RPS", setFloat(&cfg.Limit.PostRPS)},
+		{"SNIPPETBOX_LIMIT_POST_BURST", setInt(&cfg.Limit.PostBurst)},
+		{"SNIPPETBOX_LIMIT_EXEMPT", setString(&cfg.Limit.Exempt)},
+		{"SNIPPETBOX_PURGE_INTERVAL", setDuration(&cfg.Purge.Interval)},
+		{"SNIPPETBOX_PURGE_RETENTION", setDuration(&cfg.Purge.Retention)},
+		{"SNIPPETBOX_TASK_WORKERS", setInt(&cfg.Tasks.Workers)},
+		{"SNIPPETBOX_MAIL_PROVIDER", setString(&cfg.Mail.Provider)},
+		{"SNIPPETBOX_MAIL_SENDER", setString(&cfg.Mail.Sender)},
+		{"SNIPPETBOX_MAIL_API_KEY", setString(&cfg.Mail.APIKey)},
+		{"SNIPPETBOX_MAIL_API_KEY_FILE", setSecretFile(&cfg.Mail.APIKey)},
+		{"SNIPPETBOX_MAIL_DOMAIN", setString(&cfg.Mail.Domain)},
+		{"SNIPPETBOX_MAIL_REGION", setString(&cfg.Mail.Region)},
+		{"SNIPPETBOX_MAIL_ACCESS_KEY", setString(&cfg.Mail.AccessKey)},
+		{"SNIPPETBOX_MAIL_SECRET_KEY", setString(&cfg.Mail.SecretKey)},
+		{"SNIPPETBOX_MAIL_SECRET_KEY_FILE", setSecretFile(&cfg.Mail.SecretKey)},
+		{"SNIPPETBOX_SMTP_HOST", setString(&cfg.SMTP.Host)},
+		{"SNIPPETBOX_SMTP_PORT", setInt(&cfg.SMTP.Port)},
+		{"SNIPPETBOX_SMTP_USERNAME", setString(&cfg.SMTP.Username)},
+		{"SNIPPETBOX_SMTP_PASSWORD", setString(&cfg.SMTP.Password)},
+		{"SNIPPETBOX_SMTP_PASSWORD_FILE", setSecretFile(&cfg.SMTP.Password)},
+		{"SNIPPETBOX_SMTP_TLS", setBool(&cfg.SMTP.TLS)},
+		{"SNIPPETBOX_CONTACT_EMAIL", setString(&cfg.Contact.Email)},
+		{"SNIPPETBOX_SECURITY_CONTACT", setString(&cfg.Security.Contact)},
+		{"SNIPPETBOX_SECURITY_POLICY", setString(&cfg.Security.Policy)},
+		{"SNIPPETBOX_SECURITY_EXPIRY", setDuration(&cfg.Security.Expiry)},
+		{"SNIPPETBOX_LOG_FORMAT", setString(&cfg.Log.Format)},
+		{"SNIPPETBOX_LOG_LEVEL", setString(&cfg.Log.Level)},
+		{"SNIPPETBOX_LOG_OUTPUT", setString(&cfg.Log.Output)},
+		{"SNIPPETBOX_ACCESS_LOG", setString(&cfg.Log.AccessLog)},
+		{"SNIPPETBOX_SENTRY_DSN", setString(&cfg.Sentry.DSN)},
+		{"SNIPPETBOX_SENTRY_DSN_FILE", setSecretFile(&cfg.Sentry.DSN)},
+	}
+
+	for _, v := range vars {
+		value, ok := os.LookupEnv(v.name)
+		if !ok {
+			continue
+		}
+		if err := v.set(value); err != nil {
+			return fmt.Errorf("config: %s: %w", v.name, err)
+		}
+	}
+
+	return nil
+}
+
+func setString(dst *string) func(string) error {
+	return func(v string) error {
+		*dst = v
+		return nil
+	}
+}
+
+func setBool(dst *bool) func(string) error {
+	return func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*dst = b
+		return nil
+	}
+}
+
+// setSecretFile reads the secret value from the named file, so secrets can
+// be supplied via mounted Docker/Kubernetes secrets instead of appearing in
+// process args or environment dumps.
+func setSecretFile(dst *string) func(string) error {
+	return func(path string) error {
+		v, err := readSecretFile(path)
+		if err != nil {
+			return err
+		}
+		*dst = v
+		return nil
+	}
+}
+
+// readSecretFile returns the contents of path with surrounding whitespace
+// (typically a trailing newline) removed.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("config: reading secret file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func setInt(dst *int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*dst = n
+		return nil
+	}
+}
+
+func setFloat(dst *float64) func(string) error {
+	return func(v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		*dst = f
+		return nil
+	}
+}
+
+func setDuration(dst *time.Duration) func(string) error {
+	return func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*dst = d
+		return nil
+	}
+}
+
+// addrList collects repeated -addr flags into one comma-separated
+// string, the same form the setting takes in YAML and the environment.
+// The first address serves the full application; any further addresses
+// serve only the operational endpoints (health, metrics, pprof).
+type addrList struct {
+	value string
+	set   bool
+}
+
+func newAddrList(def string) *addrList {
+	return &addrList{value: def}
+}
+
+func (a *addrList) String() string {
+	return a.value
+}
+
+func (a *addrList) Set(v string) error {
+	if !a.set {
+		a.value = v
+		a.set = true
+		return nil
+	}
+	a.value += "," + v
+	return nil
+}
+
+// parseCIDRs parses a comma-separated list of CIDR networks, returning
+// nil for the empty string.
+func parseCIDRs(s string) ([]*net.IPNet, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var nets []*net.IPNet
+	for _, part := range strings.Split(s, ",") {
+		_, n, err := net.ParseCIDR(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+// parseTLSMinVersion maps a "1.2" or "1.3" setting to the corresponding
+// tls.Version constant.
+func parseTLSMinVersion(s string) (uint16, error) {
+	switch s {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("server.tls_min_version %q: must be 1.2 or 1.3", s)
+	}
+}
+
+// parseTLSCurves parses a comma-separated list of curve preference names.
+func parseTLSCurves(s string) ([]tls.CurveID, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var curves []tls.CurveID
+	for _, part := range strings.Split(s, ",") {
+		switch strings.TrimSpace(part) {
+		case "X25519":
+			curves = append(curves, tls.X25519)
+		case "P256":
+			curves = append(curves, tls.CurveP256)
+		case "P384":
+			curves = append(curves, tls.CurveP384)
+		case "P521":
+			curves = append(curves, tls.CurveP521)
+		default:
+			return nil, fmt.Errorf("server.tls_curves %q: must be X25519, P256, P384 or P521", strings.TrimSpace(part))
+		}
+	}
+
+	return curves, nil
+}
+
+// parseTLSCiphers parses a comma-separated list of TLS 1.2 cipher suite
+// names as reported by tls.CipherSuites, returning nil (the Go defaults)
+// for the empty string. TLS 1.3 suites are not configurable in
+// crypto/tls and are rejected.
+func parseTLSCiphers(s string) ([]uint16, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	byName := map[string]uint16{}
+	for _, suite := range tls.CipherSuites() {
+		byName[suite.Name] = suite.ID
+	}
+
+	var ids []uint16
+	for _, part := range strings.Split(s, ",") {
+		name := strings.TrimSpace(part)
+		id, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("server.tls_ciphers %q: unknown cipher suite", name)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// parseSameSite maps the configured SameSite mode to its http constant.
+func parseSameSite(s string) (http.SameSite, error) {
+	switch s {
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("session.same_site %q: must be lax, strict or none", s)
+	}
+}
+
+// parseSessionKeys decodes the comma-separated hex keys for the cookie
+// session store. Each key must be 32 bytes (64 hex characters); the first
+// is used to encrypt new cookies and the rest only to decrypt, which is
+// how keys are rotated.
+func parseSessionKeys(s string) ([][]byte, error) {
+	if s == "" {
+		return nil, errors.New("session.keys must be set when session.store is cookie")
+	}
+
+	var keys [][]byte
+	for _, part := range strings.Split(s, ",") {
+		key, err := hex.DecodeString(strings.TrimSpace(part))
+		if err != nil || len(key) != 32 {
+			return nil, errors.New("session.keys entries must each be 64 hex characters")
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// loadConfigFile unmarshals the YAML file at path over cfg, so settings
+// absent from the file keep their current (default) values.
+func loadConfigFile(path string, cfg *config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("config: parsing %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// mailEnabled reports whether the selected mail provider has the
+// configuration that turns email on: a host for smtp, credentials for
+// the API providers. Without it the application stores messages but
+// sends nothing.
+func (cfg config) mailEnabled() bool {
+	switch cfg.Mail.Provider {
+	case "ses":
+		return cfg.Mail.AccessKey != ""
+	case "sendgrid", "mailgun":
+		return cfg.Mail.APIKey != ""
+	default:
+		return cfg.SMTP.Host != ""
+	}
+}
+
+// validate checks the resolved configuration and returns an error listing
+// every problem found, so operators can fix a bad config in one pass.
+func (cfg config) validate() error {
+	var problems []string
+
+	if cfg.Server.Addr == "" {
+		problems = append(problems, "server.addr must not be empty")
+	}
+	if cfg.Server.Autocert && cfg.Server.AutocertHosts == "" {
+		problems = append(problems, "server.autocert_hosts must list at least one hostname when autocert is enabled")
+	}
+	if cfg.Server.Autocert && !cfg.Server.TLS {
+		problems = append(problems, "server.autocert requires server.tls")
+	}
+	if _, err := parseCIDRs(cfg.Server.TrustedProxies); err != nil {
+		problems = append(problems, fmt.Sprintf("server.trusted_proxies: %v", err))
+	}
+	if cfg.Server.HSTSMaxAge < 0 {
+		problems = append(problems, "server.hsts_max_age must not be negative")
+	}
+	switch cfg.Server.FrameOptions {
+	case "", "deny", "sameorigin":
+	default:
+		problems = append(problems, fmt.Sprintf("server.frame_options %q: must be deny, sameorigin or empty", cfg.Server.FrameOptions))
+	}
+	if cfg.Server.HandlerTimeout < 0 {
+		problems = append(problems, "server.handler_timeout must not be negative")
+	}
+	if _, err := parseTLSMinVersion(cfg.Server.TLSMinVersion); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := parseTLSCurves(cfg.Server.TLSCurves); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := parseTLSCiphers(cfg.Server.TLSCiphers); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if bp := cfg.Server.BasePath; bp != "" && (!strings.HasPrefix(bp, "/") || strings.HasSuffix(bp, "/")) {
+		problems = append(problems, fmt.Sprintf("server.base_path %q: must start with / and not end with /", bp))
+	}
+	if u := cfg.Server.AssetURL; u != "" && (!strings.HasPrefix(u, "http") || strings.HasSuffix(u, "/")) {
+		problems = append(problems, fmt.Sprintf("server.asset_url %q: must be an absolute URL without a trailing /", u))
+	}
+	if u := cfg.Server.BaseURL; u != "" && (!strings.HasPrefix(u, "http") || strings.HasSuffix(u, "/")) {
+		problems = append(problems, fmt.Sprintf("server.base_url %q: must be an absolute URL without a trailing /", u))
+	}
+
+	if _, err := time.LoadLocation(cfg.Server.Timezone); err != nil {
+		problems = append(problems, fmt.Sprintf("server.timezone %q: unknown timezone", cfg.Server.Timezone))
+	}
+	if cfg.DB.Driver != "mysql" && cfg.DB.Driver != "sqlite3" {
+		problems = append(problems, fmt.Sprintf("db.driver %q: must be mysql or sqlite3", cfg.DB.Driver))
+	}
+	if cfg.DB.DSN == "" {
+		problems = append(problems, "db.dsn must not be empty")
+	}
+	if cfg.DB.MaxOpenConns < 0 || cfg.DB.MaxIdleConns < 0 {
+		problems = append(problems, "db.max_open_conns and db.max_idle_conns must not be negative")
+	}
+	if (cfg.DB.TLSCert == "") != (cfg.DB.TLSKey == "") {
+		problems = append(problems, "db.tls_cert and db.tls_key must be set together")
+	}
+	if cfg.DB.Driver != "mysql" && (cfg.DB.TLSCA != "" || cfg.DB.TLSCert != "") {
+		problems = append(problems, "db.tls_* settings only apply to the mysql driver")
+	}
+	if _, err := parseCIDRs(cfg.Access.Allow); err != nil {
+		problems = append(problems, fmt.Sprintf("access.allow: %v", err))
+	}
+	if _, err := parseCIDRs(cfg.Access.Block); err != nil {
+		problems = append(problems, fmt.Sprintf("access.block: %v", err))
+	}
+	if cfg.Limit.RPS < 0 || cfg.Limit.PostRPS < 0 {
+		problems = append(problems, "limit.rps and limit.post_rps must not be negative")
+	}
+	if cfg.Limit.RPS > 0 && cfg.Limit.Burst < 1 {
+		problems = append(problems, "limit.burst must be at least 1 when rate limiting is enabled")
+	}
+	if cfg.Limit.PostRPS > 0 && cfg.Limit.PostBurst < 1 {
+		problems = append(problems, "limit.post_burst must be at least 1 when the POST limit is enabled")
+	}
+	if _, err := parseCIDRs(cfg.Limit.Exempt); err != nil {
+		problems = append(problems, fmt.Sprintf("limit.exempt: %v", err))
+	}
+	if cfg.Session.CookieName == "" {
+		problems = append(problems, "session.cookie_name must not be empty")
+	}
+	if cfg.Session.CookiePath == "" {
+		problems = append(problems, "session.cookie_path must not be empty")
+	}
+	if _, err := parseSameSite(cfg.Session.SameSite); err != nil {
+		problems = append(problems, err.Error())
+	} else if cfg.Session.SameSite == "none" && !cfg.Server.TLS {
+		problems = append(problems, "session.same_site none requires server.tls, since SameSite=None cookies must be Secure")
+	}
+	switch cfg.Mail.Provider {
+	case "smtp":
+		if cfg.SMTP.Host != "" && (cfg.SMTP.Port < 1 || cfg.SMTP.Port > 65535) {
+			problems = append(problems, "smtp.port must be between 1 and 65535")
+		}
+	case "ses":
+		if cfg.mailEnabled() {
+			if cfg.Mail.Region == "" {
+				problems = append(problems, "mail.region must be set for the ses provider")
+			}
+			if cfg.Mail.SecretKey == "" {
+				problems = append(problems, "mail.secret_key must be set when mail.access_key is set")
+			}
+		}
+	case "sendgrid":
+	case "mailgun":
+		if cfg.mailEnabled() && cfg.Mail.Domain == "" {
+			problems = append(problems, "mail.domain must be set for the mailgun provider")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("mail.provider %q: must be smtp, ses, sendgrid or mailgun", cfg.Mail.Provider))
+	}
+	if cfg.mailEnabled() {
+		if cfg.Mail.Sender == "" {
+			problems = append(problems, "mail.sender must be set when a mail provider is configured")
+		} else if _, err := mail.ParseAddress(cfg.Mail.Sender); err != nil {
+			problems = append(problems, fmt.Sprintf("mail.sender %q: not a valid email address", cfg.Mail.Sender))
+		}
+	}
+	if e := cfg.Contact.Email; e != "" {
+		if _, err := mail.ParseAddress(e); err != nil {
+			problems = append(problems, fmt.Sprintf("contact.email %q: not a valid email address", e))
+		}
+	}
+	if cfg.Security.Contact != "" && cfg.Security.Expiry <= 0 {
+		problems = append(problems, "security.expiry must be positive when security.contact is set")
+	}
+	if cfg.Session.Lifetime <= 0 {
+		problems = append(problems, "session.lifetime must be positive")
+	}
+	switch cfg.Session.Store {
+	case "db", "memory":
+	case "redis":
+		if cfg.Session.RedisAddr == "" {
+			problems = append(problems, "session.redis_addr must be set when session.store is redis")
+		}
+	case "cookie":
+		if _, err := parseSessionKeys(cfg.Session.Keys); err != nil {
+			problems = append(problems, err.Error())
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("session.store %q: must be db, redis, memory or cookie", cfg.Session.Store))
+	}
+	if cfg.Purge.Interval < 0 || cfg.Purge.Retention < 0 {
+		problems = append(problems, "purge.interval and purge.retention must not be negative")
+	}
+	if cfg.Tasks.Workers < 0 {
+		problems = append(problems, "tasks.workers must not be negative")
+	}
+	if cfg.Log.Format != "text" && cfg.Log.Format != "json" {
+		problems = append(problems, fmt.Sprintf("log.format %q: must be text or json", cfg.Log.Format))
+	}
+	if _, err := parseLogLevel(cfg.Log.Level); err != nil {
+		problems = append(problems, fmt.Sprintf("log.level %q: must be debug, info, warn or error", cfg.Log.Level))
+	}
+	if cfg.Log.Output != "stdout" && cfg.Log.Output != "syslog" {
+		problems = append(problems, fmt.Sprintf("log.output %q: must be stdout or syslog", cfg.Log.Output))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package main
+
+type contextKey string
+
+// logContextKey locates the per-request *logContext placed in the request
+// context by logRequest.
+const logContextKey = contextKey("logContext")
+
+// requestIDContextKey locates the request's correlation ID in the request
+// context.
+const requestIDContextKey = contextKey("requestID")
+
+// cspNonceContextKey locates the per-request CSP nonce, for templates
+// that need to allow an inline script or style.
+const cspNonceContextKey = contextKey("cspNonce")
+
+// logContext carries request-scoped attributes that only become known
+// further down the middleware chain (e.g. once the session has been
+// loaded), back out to the logging middleware.
+type logContext struct {
+	userID int
+}
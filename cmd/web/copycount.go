@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// copyFlushInterval is how often the batched copy counts are written to
+// the database.
+const copyFlushInterval = 30 * time.Second
+
+// copyCounter accumulates copy events in memory, so each beacon costs a
+// map increment rather than a database write; a scheduled job flushes
+// the batch.
+type copyCounter struct {
+	mu     sync.Mutex
+	counts map[int]int
+}
+
+func (c *copyCounter) add(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts == nil {
+		c.counts = map[int]int{}
+	}
+	c.counts[id]++
+}
+
+// drain returns the accumulated counts and resets the counter.
+func (c *copyCounter) drain() map[int]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := c.counts
+	c.counts = nil
+	return counts
+}
+
+// snippetCopiedPost is the beacon the view page fires after copying a
+// snippet to the clipboard. Like /csp-report it sits outside the noSurf
+// chain, since navigator.sendBeacon cannot carry a CSRF token; the
+// worst a forger can do is nudge a counter.
+func (app *application) snippetCopiedPost(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	app.copyCounts.add(id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runCopyFlush writes the batched copy counts to the database; the
+// scheduler runs it every copyFlushInterval.
+func (app *application) runCopyFlush() error {
+	counts := app.copyCounts.drain()
+	if len(counts) == 0 {
+		return nil
+	}
+
+	return app.snippets.AddCopies(counts)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxCSPReports caps how many recent violation reports are kept in
+// memory for the admin dashboard.
+const maxCSPReports = 50
+
+// cspReport is one violation report in the shape browsers POST it.
+type cspReport struct {
+	DocumentURI       string `json:"document-uri"`
+	ViolatedDirective string `json:"violated-directive"`
+	BlockedURI        string `json:"blocked-uri"`
+	SourceFile        string `json:"source-file"`
+	LineNumber        int    `json:"line-number"`
+
+	Received time.Time `json:"-"`
+}
+
+// cspReportLog holds the most recent violation reports, newest first.
+type cspReportLog struct {
+	mu      sync.Mutex
+	reports []cspReport
+}
+
+func (l *cspReportLog) add(r cspReport) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reports = append([]cspReport{r}, l.reports...)
+	if len(l.reports) > maxCSPReports {
+		l.reports = l.reports[:maxCSPReports]
+	}
+}
+
+func (l *cspReportLog) recent() []cspReport {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]cspReport(nil), l.reports...)
+}
+
+// cspReportPost accepts violation reports from browsers, for policies
+// that include a report-uri directive pointing at /csp-report. Reports
+// are logged and kept for the admin dashboard; a per-IP limiter drops
+// floods silently, since the sender is a browser that won't retry.
+func (app *application) cspReportPost(w http.ResponseWriter, r *http.Request) {
+	if ok, _ := app.cspLimiter.Allow(app.clientIP(r)); !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var payload struct {
+		Report cspReport `json:"csp-report"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	payload.Report.Received = time.Now()
+	app.cspReports.add(payload.Report)
+
+	app.logger.Warn("csp violation",
+		"document", payload.Report.DocumentURI,
+		"directive", payload.Report.ViolatedDirective,
+		"blocked", payload.Report.BlockedURI)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminCSPReports shows the recent violation reports, so the policy can
+// be tightened against real data.
+func (app *application) adminCSPReports(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	data.CSPReports = app.cspReports.recent()
+	data.Breadcrumbs = app.breadcrumbs(r, breadcrumb{Label: "CSP Reports"})
+
+	app.render(w, r, http.StatusOK, "csp-reports.tmpl", data)
+}
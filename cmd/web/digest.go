@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/models"
+)
+
+// digestCheckInterval is how often the digest job wakes to see whether
+// a new ISO week has started; the digest itself goes out once per week.
+const digestCheckInterval = time.Hour
+
+// digestSnippetCount is how many popular snippets a digest includes.
+const digestSnippetCount = 5
+
+// digestWeekSetting is the settings-table key recording the last ISO
+// week a digest went out for, so restarts don't resend it.
+const digestWeekSetting = "digest_last_week"
+
+// runDigest is the scheduled weekly digest job. Once per ISO week it
+// emails the week's most popular new snippets to every user who opted
+// in on the notification preferences page, dispatching each email
+// through the task queue. A week with no new snippets sends nothing.
+// Without a configured mailer the job does nothing at all.
+func (app *application) runDigest() error {
+	if app.mailer == nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	year, week := now.ISOWeek()
+	current := fmt.Sprintf("%d-W%02d", year, week)
+
+	last, ok, err := app.settingStore.Get(digestWeekSetting)
+	if err != nil {
+		return err
+	}
+	if ok && last == current {
+		return nil
+	}
+
+	snippets, err := app.snippets.Popular(now.AddDate(0, 0, -7), digestSnippetCount)
+	if err != nil {
+		return err
+	}
+
+	if len(snippets) > 0 {
+		users, err := app.notifications.EmailSubscribers(models.NotificationDigest)
+		if err != nil {
+			return err
+		}
+
+		var items []map[string]any
+		for _, s := range snippets {
+			items = append(items, map[string]any{
+				"Title":  s.Title,
+				"Copies": s.Copies,
+				"URL":    app.cfg.Server.BaseURL + app.path(fmt.Sprintf("/snippet/view/%d", s.ID)),
+			})
+		}
+
+		for _, u := range users {
+			app.sendEmail(u.Email, "weekly_digest.tmpl", map[string]any{
+				"Name":     u.Name,
+				"Week":     current,
+				"Snippets": items,
+			})
+		}
+
+		app.logger.Info("queued weekly digest", "week", current, "recipients", len(users))
+	}
+
+	return app.settingStore.Set(digestWeekSetting, current)
+}
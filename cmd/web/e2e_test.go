@@ -0,0 +1,143 @@
+//go:build e2e
+
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"snippetbox.ayubasayyed.net/internal/migrations"
+	"snippetbox.ayubasayyed.net/internal/models"
+)
+
+// newE2EApplication builds an application backed by a freshly migrated
+// throwaway SQLite database, so end-to-end tests exercise the real
+// models and schema rather than mocks. Run these with:
+//
+//	go test -tags e2e ./cmd/web/
+func newE2EApplication(t *testing.T) *application {
+	t.Helper()
+
+	app := newTestApplication(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "e2e.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := migrations.Up(db, "sqlite3"); err != nil {
+		t.Fatal(err)
+	}
+
+	app.db = db
+	app.snippets = &models.SnippetModel{DB: db}
+	app.users = &models.UserModel{DB: db}
+	app.comments = &models.CommentModel{DB: db}
+	app.notifications = &models.NotificationModel{DB: db}
+	app.reactions = &models.ReactionModel{DB: db}
+	app.contactMsgs = &models.ContactModel{DB: db}
+	app.loginDevices = &models.LoginDeviceModel{DB: db}
+	app.settingStore = &models.SettingModel{DB: db}
+
+	return app
+}
+
+// TestE2EUserJourney walks the core user journey end to end: signup,
+// login, creating a snippet, viewing it, and logging out, asserting on
+// the flash messages and redirects along the way. The cookie jar in
+// testServer carries the session across steps.
+func TestE2EUserJourney(t *testing.T) {
+	app := newE2EApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	// Signup.
+	_, _, body := ts.get(t, "/user/signup")
+	csrfToken := extractCSRFToken(t, body)
+
+	form := url.Values{}
+	form.Add("name", "Bob")
+	form.Add("email", "bob@example.com")
+	form.Add("password", "validPa$$word")
+	form.Add("password_confirm", "validPa$$word")
+	form.Add("csrf_token", csrfToken)
+
+	code, headers, _ := ts.postForm(t, "/user/signup", form)
+	if code != http.StatusSeeOther {
+		t.Fatalf("signup: got status %d; want %d", code, http.StatusSeeOther)
+	}
+	if headers.Get("Location") != "/user/login" {
+		t.Fatalf("signup: got location %q; want %q", headers.Get("Location"), "/user/login")
+	}
+
+	// Login, which should show the signup flash.
+	_, _, body = ts.get(t, "/user/login")
+	if want := "Your signup was successful. Please log in."; !strings.Contains(body, want) {
+		t.Errorf("login page doesn't contain flash %q", want)
+	}
+	csrfToken = extractCSRFToken(t, body)
+
+	form = url.Values{}
+	form.Add("email", "bob@example.com")
+	form.Add("password", "validPa$$word")
+	form.Add("csrf_token", csrfToken)
+
+	code, headers, _ = ts.postForm(t, "/user/login", form)
+	if code != http.StatusSeeOther {
+		t.Fatalf("login: got status %d; want %d", code, http.StatusSeeOther)
+	}
+
+	// Create a snippet.
+	_, _, body = ts.get(t, "/snippet/create")
+	csrfToken = extractCSRFToken(t, body)
+
+	form = url.Values{}
+	form.Add("title", "An old silent pond")
+	form.Add("content", "An old silent pond...")
+	form.Add("expires", "7")
+	form.Add("csrf_token", csrfToken)
+
+	code, headers, _ = ts.postForm(t, "/snippet/create", form)
+	if code != http.StatusSeeOther {
+		t.Fatalf("create: got status %d; want %d", code, http.StatusSeeOther)
+	}
+
+	// View it, which should show the creation flash.
+	code, _, body = ts.get(t, headers.Get("Location"))
+	if code != http.StatusOK {
+		t.Fatalf("view: got status %d; want %d", code, http.StatusOK)
+	}
+	if want := "Snippet successfully created!"; !strings.Contains(body, want) {
+		t.Errorf("view page doesn't contain flash %q", want)
+	}
+	if want := "An old silent pond"; !strings.Contains(body, want) {
+		t.Errorf("view page doesn't contain title %q", want)
+	}
+
+	// Logout.
+	form = url.Values{}
+	form.Add("csrf_token", csrfToken)
+
+	code, headers, _ = ts.postForm(t, "/user/logout", form)
+	if code != http.StatusSeeOther {
+		t.Fatalf("logout: got status %d; want %d", code, http.StatusSeeOther)
+	}
+
+	_, _, body = ts.get(t, headers.Get("Location"))
+	if want := "You&#39;ve been logged out successfully!"; !strings.Contains(body, want) {
+		t.Errorf("home page doesn't contain flash %q", want)
+	}
+
+	// The create page requires authentication again.
+	code, headers, _ = ts.get(t, "/snippet/create")
+	if code != http.StatusSeeOther {
+		t.Errorf("create after logout: got status %d; want %d", code, http.StatusSeeOther)
+	}
+	if headers.Get("Location") != "/user/login" {
+		t.Errorf("create after logout: got location %q; want %q", headers.Get("Location"), "/user/login")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Flash levels. The level ends up as a CSS class on the rendered
+// message, so they stay lowercase.
+const (
+	flashLevelSuccess = "success"
+	flashLevelError   = "error"
+	flashLevelWarning = "warning"
+)
+
+// flashMessage is one queued flash notification with its severity.
+type flashMessage struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// flash queues a message to show on the next rendered page. The queue
+// is stored in the session as a JSON string so it round-trips through
+// every session backend unchanged.
+func (app *application) flash(r *http.Request, level, message string) {
+	queued := decodeFlashes(app.sessionManager.PopString(r.Context(), "flash"))
+	queued = append(queued, flashMessage{Level: level, Message: message})
+
+	b, err := json.Marshal(queued)
+	if err != nil {
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", string(b))
+}
+
+// flashSuccess queues a success message for the next page.
+func (app *application) flashSuccess(r *http.Request, message string) {
+	app.flash(r, flashLevelSuccess, message)
+}
+
+// flashError queues an error message for the next page.
+func (app *application) flashError(r *http.Request, message string) {
+	app.flash(r, flashLevelError, message)
+}
+
+// flashWarning queues a warning message for the next page.
+func (app *application) flashWarning(r *http.Request, message string) {
+	app.flash(r, flashLevelWarning, message)
+}
+
+// decodeFlashes turns the stored session value back into the queue. A
+// value that predates the JSON encoding is shown as a single success
+// flash rather than dropped.
+func decodeFlashes(raw string) []flashMessage {
+	if raw == "" {
+		return nil
+	}
+
+	var queued []flashMessage
+	if err := json.Unmarshal([]byte(raw), &queued); err != nil {
+		return []flashMessage{{Level: flashLevelSuccess, Message: raw}}
+	}
+
+	return queued
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"mime/multipart"
+	"net/http"
+)
+
+// validatingForm is satisfied by any form struct that embeds
+// validator.Validator.
+type validatingForm interface {
+	Valid() bool
+}
+
+// fileCarrier is implemented by form structs that want the uploaded file
+// headers from a multipart body delivered alongside their decoded
+// fields. Embedding fileFields is the usual way to satisfy it.
+type fileCarrier interface {
+	setFileHeaders(files map[string][]*multipart.FileHeader)
+}
+
+// fileFields can be embedded in a form struct to receive the uploaded
+// file headers when the request body is multipart. For urlencoded
+// bodies Files stays nil.
+type fileFields struct {
+	Files map[string][]*multipart.FileHeader `form:"-"`
+}
+
+func (f *fileFields) setFileHeaders(files map[string][]*multipart.FileHeader) {
+	f.Files = files
+}
+
+// File returns the first uploaded file header for the named field, or
+// nil when no file was submitted under that name.
+func (f *fileFields) File(name string) *multipart.FileHeader {
+	if headers := f.Files[name]; len(headers) > 0 {
+		return headers[0]
+	}
+	return nil
+}
+
+// handleForm wraps the decode → validate → re-render-on-error dance
+// shared by every POST handler. It decodes the request body into form
+// (a pointer to the form struct), runs validate to attach any errors,
+// and when the form is invalid re-renders page with 422 Unprocessable
+// Entity. It reports whether the handler should carry on with its
+// success path.
+//
+// The optional fill callback populates any extra template data the page
+// needs when re-rendering (the snippet view page needs the snippet and
+// its comments, for example). fill reports whether it succeeded; on
+// false it must already have written a response.
+func handleForm[F validatingForm](app *application, w http.ResponseWriter, r *http.Request, form F, page string, validate func(), fill func(*templateData) bool) bool {
+	err := app.decodePostForm(r, form)
+	if err != nil {
+		app.clientError(w, formErrorStatus(err))
+		return false
+	}
+
+	validate()
+
+	if form.Valid() {
+		return true
+	}
+
+	data := app.newTemplateData(r)
+	if fill != nil && !fill(data) {
+		return false
+	}
+	data.Form = form
+
+	app.render(w, r, http.StatusUnprocessableEntity, page, data)
+
+	return false
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/form/v4"
+)
+
+func TestDecodePostFormMultipart(t *testing.T) {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+
+	if err := mw.WriteField("title", "An old silent pond"); err != nil {
+		t.Fatal(err)
+	}
+	fw, err := mw.CreateFormFile("attachment", "pond.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte("A frog jumps into the pond"))
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	app := &application{formDecoder: form.NewDecoder()}
+
+	var dst struct {
+		Title string `form:"title"`
+		fileFields
+	}
+
+	if err := app.decodePostForm(r, &dst); err != nil {
+		t.Fatalf("decodePostForm() error = %v", err)
+	}
+
+	if dst.Title != "An old silent pond" {
+		t.Errorf("Title = %q; want the decoded field", dst.Title)
+	}
+
+	fh := dst.File("attachment")
+	if fh == nil {
+		t.Fatal("File(attachment) = nil; want the uploaded file header")
+	}
+	if fh.Filename != "pond.txt" {
+		t.Errorf("Filename = %q; want %q", fh.Filename, "pond.txt")
+	}
+	if dst.File("missing") != nil {
+		t.Error("File(missing) != nil; want nil")
+	}
+}
+
+func TestDecodePostFormURLEncoded(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("title=hello"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	app := &application{formDecoder: form.NewDecoder()}
+
+	var dst struct {
+		Title string `form:"title"`
+		fileFields
+	}
+
+	if err := app.decodePostForm(r, &dst); err != nil {
+		t.Fatalf("decodePostForm() error = %v", err)
+	}
+	if dst.Title != "hello" {
+		t.Errorf("Title = %q; want %q", dst.Title, "hello")
+	}
+	if dst.Files != nil {
+		t.Error("Files != nil for an urlencoded body; want nil")
+	}
+}
+
+func TestFormErrorStatus(t *testing.T) {
+	if got := formErrorStatus(&http.MaxBytesError{Limit: 1024}); got != http.StatusRequestEntityTooLarge {
+		t.Errorf("formErrorStatus(MaxBytesError) = %d; want 413", got)
+	}
+	if got := formErrorStatus(multipart.ErrMessageTooLarge); got != http.StatusRequestEntityTooLarge {
+		t.Errorf("formErrorStatus(ErrMessageTooLarge) = %d; want 413", got)
+	}
+	if got := formErrorStatus(http.ErrNotMultipart); got != http.StatusBadRequest {
+		t.Errorf("formErrorStatus(other) = %d; want 400", got)
+	}
+}
+
+func FuzzDecodePostForm(f *testing.F) {
+	f.Add("title=hello&content=world", "application/x-www-form-urlencoded")
+	f.Add("title=%zz", "application/x-www-form-urlencoded")
+	f.Add("a=1;b=2", "application/x-www-form-urlencoded")
+	f.Add("--x\r\nContent-Disposition: form-data; name=\"title\"\r\n\r\nhi\r\n--x--\r\n", "multipart/form-data; boundary=x")
+	f.Add("--x\r\nbroken", "multipart/form-data; boundary=x")
+	f.Add("", "multipart/form-data")
+	f.Add("title=hello", "text/plain; charset=\xff")
+
+	f.Fuzz(func(t *testing.T, body, contentType string) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+
+		app := &application{formDecoder: form.NewDecoder()}
+
+		var dst struct {
+			Title   string `form:"title"`
+			Content string `form:"content"`
+			Expires int    `form:"expires"`
+			fileFields
+		}
+
+		// Malformed bodies should come back as errors, never panics.
+		app.decodePostForm(r, &dst)
+	})
+}
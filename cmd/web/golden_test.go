@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/i18n"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// TestTemplateGolden renders every page template with the same
+// representative data check-templates uses, pinned to a fixed time, and
+// compares the markup against the golden files in testdata/golden.
+// After an intentional template change, refresh them with:
+//
+//	go test -run TestTemplateGolden -update ./cmd/web/
+func TestTemplateGolden(t *testing.T) {
+	translator, err := i18n.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := newTemplateCache("", "", translator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 3, 17, 10, 30, 0, 0, time.UTC)
+
+	for page, ts := range cache {
+		t.Run(page, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := ts.ExecuteTemplate(&buf, "base", sampleTemplateData(page, now)); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "golden", page+".html")
+
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file (run with -update to create it): %v", err)
+			}
+
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("rendered markup differs from %s (run with -update if the change is intentional)", golden)
+			}
+		})
+	}
+}
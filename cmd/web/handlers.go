@@ -0,0 +1,504 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/feed"
+	"snippetbox.ayubasayyed.net/internal/models"
+	"snippetbox.ayubasayyed.net/internal/validator"
+
+	"github.com/gorilla/websocket"
+	"github.com/julienschmidt/httprouter"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// snippetCreateForm represents the form data and validation errors for the
+// snippet create form.
+type snippetCreateForm struct {
+	Title               string `form:"title"`
+	Content             string `form:"content"`
+	Expires             int    `form:"expires"`
+	validator.Validator `form:"-"`
+}
+
+// commentCreateForm represents the form data and validation errors for the
+// comment form on the snippet view page.
+type commentCreateForm struct {
+	Body                string `form:"body"`
+	validator.Validator `form:"-"`
+}
+
+// userSignupForm represents the form data and validation errors for the
+// user signup form.
+type userSignupForm struct {
+	Name                string `form:"name"`
+	Email               string `form:"email"`
+	Password            string `form:"password"`
+	PasswordConfirm     string `form:"password_confirm"`
+	validator.Validator `form:"-"`
+}
+
+// userLoginForm represents the form data and validation errors for the
+// user login form.
+type userLoginForm struct {
+	Email               string `form:"email"`
+	Password            string `form:"password"`
+	validator.Validator `form:"-"`
+}
+
+// homePerPage is how many snippets the home page lists at a time.
+const homePerPage = 10
+
+func (app *application) home(w http.ResponseWriter, r *http.Request) {
+	page := pageParam(r)
+
+	total, err := app.snippets.Count()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	snippets, err := app.snippets.List(homePerPage, (page-1)*homePerPage)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippets = snippets
+	data.Pagination = app.newPagination(r, page, homePerPage, total)
+
+	app.render(w, r, http.StatusOK, "home.tmpl", data)
+}
+
+func (app *application) snippetView(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	snippet, err := app.snippets.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	comments, err := app.comments.ForSnippet(id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	mentionUsers, err := app.mentionTargets(comments)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	reactions, err := app.snippetReactions(r, id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Comments = comments
+	data.MentionUsers = mentionUsers
+	data.Reactions = reactions
+	data.Form = commentCreateForm{}
+	data.Breadcrumbs = app.breadcrumbs(r, breadcrumb{Label: app.t(r, "view.title", snippet.ID)})
+
+	app.render(w, r, http.StatusOK, "view.tmpl", data)
+}
+
+func (app *application) snippetCreate(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	data.Form = snippetCreateForm{
+		Expires: app.siteSettings().DefaultExpires,
+	}
+	data.Breadcrumbs = app.breadcrumbs(r, breadcrumb{Label: app.t(r, "create.title")})
+
+	app.render(w, r, http.StatusOK, "create.tmpl", data)
+}
+
+func (app *application) snippetCreatePost(w http.ResponseWriter, r *http.Request) {
+	var form snippetCreateForm
+
+	ok := handleForm(app, w, r, &form, "create.tmpl", func() {
+		maxSize := app.siteSettings().MaxSnippetSize
+
+		form.CheckField(validator.NotBlank(form.Title), "title", app.t(r, "validation.blank"))
+		form.CheckField(validator.MaxChars(form.Title, 100), "title", app.t(r, "validation.max_chars", 100))
+		form.CheckField(validator.NotBlank(form.Content), "content", app.t(r, "validation.blank"))
+		form.CheckField(validator.MaxChars(form.Content, maxSize), "content", app.t(r, "validation.max_chars", maxSize))
+		form.CheckField(validator.PermittedValue(form.Expires, 1, 7, 365), "expires", app.t(r, "validation.expires"))
+	}, nil)
+	if !ok {
+		return
+	}
+
+	id, err := app.snippets.Insert(form.Title, form.Content, form.Expires)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	snippet, err := app.snippets.Get(id)
+	if err == nil {
+		app.firehose.Publish(feed.Event{
+			ID:      snippet.ID,
+			Title:   snippet.Title,
+			Created: snippet.Created,
+		})
+	}
+
+	app.flashSuccess(r, app.t(r, "flash.snippet_created"))
+
+	http.Redirect(w, r, app.path(fmt.Sprintf("/snippet/view/%d", id)), http.StatusSeeOther)
+}
+
+// wsFirehose upgrades the connection to a WebSocket and streams events for
+// newly created public snippets until the client disconnects.
+func (app *application) wsFirehose(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied to the client at this point, so
+		// just record the error.
+		app.logger.Error(err.Error())
+		return
+	}
+	defer conn.Close()
+
+	events := app.firehose.Subscribe()
+	defer app.firehose.Unsubscribe(events)
+
+	// Discard any incoming messages, using the read loop to detect when
+	// the client goes away.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+func (app *application) snippetCommentPost(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	var form commentCreateForm
+
+	ok := handleForm(app, w, r, &form, "view.tmpl", func() {
+		form.CheckField(validator.NotBlank(form.Body), "body", app.t(r, "validation.blank"))
+		form.CheckField(validator.MaxChars(form.Body, 1000), "body", app.t(r, "validation.max_chars", 1000))
+	}, func(data *templateData) bool {
+		snippet, err := app.snippets.Get(id)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				app.notFound(w, r)
+			} else {
+				app.serverError(w, r, err)
+			}
+			return false
+		}
+
+		comments, err := app.comments.ForSnippet(id)
+		if err != nil {
+			app.serverError(w, r, err)
+			return false
+		}
+
+		mentionUsers, err := app.mentionTargets(comments)
+		if err != nil {
+			app.serverError(w, r, err)
+			return false
+		}
+
+		reactions, err := app.snippetReactions(r, id)
+		if err != nil {
+			app.serverError(w, r, err)
+			return false
+		}
+
+		data.Snippet = snippet
+		data.Comments = comments
+		data.MentionUsers = mentionUsers
+		data.Reactions = reactions
+		return true
+	})
+	if !ok {
+		return
+	}
+
+	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+
+	commentID, err := app.comments.Insert(id, userID, form.Body)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	comment, err := app.comments.Get(commentID)
+	if err == nil {
+		app.commentFeed.Publish(feed.CommentEvent{
+			ID:        comment.ID,
+			SnippetID: comment.SnippetID,
+			Author:    comment.Author,
+			Body:      comment.Body,
+			Created:   comment.Created,
+		})
+		notified := app.notifyMentions(comment, userID)
+		app.notifyCommenters(comment.SnippetID, userID, comment.Author, notified)
+	}
+
+	http.Redirect(w, r, app.path(fmt.Sprintf("/snippet/view/%d", id)), http.StatusSeeOther)
+}
+
+// snippetEvents streams new comments on a snippet to the client as
+// server-sent events, with periodic keep-alive comments so intermediaries
+// don't drop the connection.
+func (app *application) snippetEvents(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		app.clientError(w, http.StatusNotImplemented)
+		return
+	}
+
+	// The server's WriteTimeout would sever the stream shortly after the
+	// first write, long before the keepalive ticker fires. An event
+	// stream is meant to stay open, so clear this request's deadline.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		app.logger.Error(err.Error())
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events := app.commentFeed.Subscribe(id)
+	defer app.commentFeed.Unsubscribe(id, events)
+
+	keepAlive := time.NewTicker(25 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// The hub has been closed because the server is
+				// shutting down.
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				app.logger.Error(err.Error())
+				return
+			}
+
+			fmt.Fprintf(w, "event: comment\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (app *application) userSignup(w http.ResponseWriter, r *http.Request) {
+	if !app.siteSettings().SignupOpen {
+		app.signupClosed(w, r)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Form = userSignupForm{}
+	app.render(w, r, http.StatusOK, "signup.tmpl", data)
+}
+
+// signupClosed turns signup requests away while the admin has signups
+// switched off.
+func (app *application) signupClosed(w http.ResponseWriter, r *http.Request) {
+	app.flashWarning(r, app.t(r, "flash.signups_closed"))
+
+	http.Redirect(w, r, app.path("/"), http.StatusSeeOther)
+}
+
+func (app *application) userSignupPost(w http.ResponseWriter, r *http.Request) {
+	if !app.siteSettings().SignupOpen {
+		app.signupClosed(w, r)
+		return
+	}
+
+	var form userSignupForm
+
+	ok := handleForm(app, w, r, &form, "signup.tmpl", func() {
+		form.CheckField(validator.NotBlank(form.Name), "name", app.t(r, "validation.blank"))
+		form.CheckField(validator.NotBlank(form.Email), "email", app.t(r, "validation.blank"))
+		form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", app.t(r, "validation.email"))
+		form.CheckField(validator.NotBlank(form.Password), "password", app.t(r, "validation.blank"))
+		form.CheckField(validator.MinChars(form.Password, 8), "password", app.t(r, "validation.min_chars", 8))
+		form.CheckForm(validator.Equal(form.Password, form.PasswordConfirm), "password_confirm", app.t(r, "validation.password_mismatch"))
+	}, nil)
+	if !ok {
+		return
+	}
+
+	err := app.users.Insert(form.Name, form.Email, form.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrDuplicateEmail) {
+			form.AddFieldError("email", app.t(r, "validation.email_in_use"))
+
+			data := app.newTemplateData(r)
+			data.Form = form
+			app.render(w, r, http.StatusUnprocessableEntity, "signup.tmpl", data)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.flashSuccess(r, app.t(r, "flash.signup_ok"))
+
+	http.Redirect(w, r, app.path("/user/login"), http.StatusSeeOther)
+}
+
+// userView shows a user's public profile, which mention links in comments
+// point at.
+func (app *application) userView(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	user, err := app.users.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Profile = user
+	data.Breadcrumbs = app.breadcrumbs(r, breadcrumb{Label: user.Name})
+
+	app.render(w, r, http.StatusOK, "profile.tmpl", data)
+}
+
+func (app *application) userLogin(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	data.Form = userLoginForm{}
+	app.render(w, r, http.StatusOK, "login.tmpl", data)
+}
+
+func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
+	var form userLoginForm
+
+	ok := handleForm(app, w, r, &form, "login.tmpl", func() {
+		form.CheckField(validator.NotBlank(form.Email), "email", app.t(r, "validation.blank"))
+		form.CheckField(validator.Matches(form.Email, validator.EmailRX), "email", app.t(r, "validation.email"))
+		form.CheckField(validator.NotBlank(form.Password), "password", app.t(r, "validation.blank"))
+	}, nil)
+	if !ok {
+		return
+	}
+
+	id, err := app.users.Authenticate(form.Email, form.Password)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCredentials) {
+			form.AddNonFieldError(app.t(r, "validation.credentials"))
+
+			data := app.newTemplateData(r)
+			data.Form = form
+			app.render(w, r, http.StatusUnprocessableEntity, "login.tmpl", data)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	err = app.sessionManager.RenewToken(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "authenticatedUserID", id)
+
+	app.alertNewDevice(r, id)
+
+	http.Redirect(w, r, app.path("/snippet/create"), http.StatusSeeOther)
+}
+
+func (app *application) userLogoutPost(w http.ResponseWriter, r *http.Request) {
+	err := app.sessionManager.RenewToken(r.Context())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Remove(r.Context(), "authenticatedUserID")
+
+	app.flashSuccess(r, app.t(r, "flash.logged_out"))
+
+	http.Redirect(w, r, app.path("/"), http.StatusSeeOther)
+}
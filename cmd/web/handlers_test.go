@@ -0,0 +1,318 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/cookiesession"
+	"snippetbox.ayubasayyed.net/internal/models/mocks"
+)
+
+func TestHome(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, "/")
+
+	if code != http.StatusOK {
+		t.Errorf("got status %d; want %d", code, http.StatusOK)
+	}
+	if !strings.Contains(body, mocks.Snippet.Title) {
+		t.Errorf("body doesn't contain %q", mocks.Snippet.Title)
+	}
+}
+
+func TestSnippetView(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Valid ID",
+			urlPath:  "/snippet/view/1",
+			wantCode: http.StatusOK,
+			wantBody: mocks.Snippet.Content,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/snippet/view/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Negative ID",
+			urlPath:  "/snippet/view/-1",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Non-numeric ID",
+			urlPath:  "/snippet/view/foo",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			if code != tt.wantCode {
+				t.Errorf("got status %d; want %d", code, tt.wantCode)
+			}
+			if tt.wantBody != "" && !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body doesn't contain %q", tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestUserSignupPost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	_, _, body := ts.get(t, "/user/signup")
+	csrfToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name      string
+		userName  string
+		userEmail string
+		password  string
+		confirm   string
+		csrfToken string
+		wantCode  int
+	}{
+		{
+			name:      "Valid submission",
+			userName:  "Bob",
+			userEmail: "bob@example.com",
+			password:  "validPa$$word",
+			confirm:   "validPa$$word",
+			csrfToken: csrfToken,
+			wantCode:  http.StatusSeeOther,
+		},
+		{
+			name:      "Invalid CSRF token",
+			userName:  "Bob",
+			userEmail: "bob@example.com",
+			password:  "validPa$$word",
+			confirm:   "validPa$$word",
+			csrfToken: "wrongToken",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Blank name",
+			userName:  "",
+			userEmail: "bob@example.com",
+			password:  "validPa$$word",
+			confirm:   "validPa$$word",
+			csrfToken: csrfToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid email",
+			userName:  "Bob",
+			userEmail: "bob@example.",
+			password:  "validPa$$word",
+			confirm:   "validPa$$word",
+			csrfToken: csrfToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Short password",
+			userName:  "Bob",
+			userEmail: "bob@example.com",
+			password:  "pa$$",
+			confirm:   "pa$$",
+			csrfToken: csrfToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Mismatched confirmation",
+			userName:  "Bob",
+			userEmail: "bob@example.com",
+			password:  "validPa$$word",
+			confirm:   "differentPa$$word",
+			csrfToken: csrfToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Duplicate email",
+			userName:  "Bob",
+			userEmail: "dupe@example.com",
+			password:  "validPa$$word",
+			confirm:   "validPa$$word",
+			csrfToken: csrfToken,
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("name", tt.userName)
+			form.Add("email", tt.userEmail)
+			form.Add("password", tt.password)
+			form.Add("password_confirm", tt.confirm)
+			form.Add("csrf_token", tt.csrfToken)
+
+			code, _, _ := ts.postForm(t, "/user/signup", form)
+
+			if code != tt.wantCode {
+				t.Errorf("got status %d; want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestUserLoginPost(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	_, _, body := ts.get(t, "/user/login")
+	csrfToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name         string
+		email        string
+		password     string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Valid credentials",
+			email:        mocks.User.Email,
+			password:     "pa$$word",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/create",
+		},
+		{
+			name:     "Wrong password",
+			email:    mocks.User.Email,
+			password: "wrongPa$$word",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Unknown email",
+			email:    "nobody@example.com",
+			password: "pa$$word",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("email", tt.email)
+			form.Add("password", tt.password)
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, _ := ts.postForm(t, "/user/login", form)
+
+			if code != tt.wantCode {
+				t.Errorf("got status %d; want %d", code, tt.wantCode)
+			}
+			if tt.wantLocation != "" && headers.Get("Location") != tt.wantLocation {
+				t.Errorf("got location %q; want %q", headers.Get("Location"), tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestSnippetCreate(t *testing.T) {
+	t.Run("Unauthenticated", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+
+		code, headers, _ := ts.get(t, "/snippet/create")
+
+		if code != http.StatusSeeOther {
+			t.Errorf("got status %d; want %d", code, http.StatusSeeOther)
+		}
+		if got := headers.Get("Location"); got != "/user/login" {
+			t.Errorf("got location %q; want %q", got, "/user/login")
+		}
+	})
+
+	t.Run("Authenticated", func(t *testing.T) {
+		app := newTestApplication(t)
+		ts := newTestServer(t, app.routes())
+		ts.loginAsMockUser(t)
+
+		code, _, body := ts.get(t, "/snippet/create")
+
+		if code != http.StatusOK {
+			t.Errorf("got status %d; want %d", code, http.StatusOK)
+		}
+		if !strings.Contains(body, "<form action='/snippet/create' method='POST'") {
+			t.Error("body doesn't contain the create form")
+		}
+
+		code, headers, _ := ts.submitForm(t, "/snippet/create", url.Values{
+			"title":   {"An old silent pond"},
+			"content": {"An old silent pond..."},
+			"expires": {"7"},
+		})
+
+		if code != http.StatusSeeOther {
+			t.Errorf("create: got status %d; want %d", code, http.StatusSeeOther)
+		}
+		if got := headers.Get("Location"); got != "/snippet/view/2" {
+			t.Errorf("create: got location %q; want %q", got, "/snippet/view/2")
+		}
+	})
+}
+
+// TestCookieSessionStore reruns the signup flash and login flow against
+// the stateless cookie session store, whose cookie is committed from a
+// response writer hook rather than a server-side store — the handler
+// suite otherwise runs everything against the memory store.
+func TestCookieSessionStore(t *testing.T) {
+	app := newTestApplication(t)
+
+	manager, err := cookiesession.New([][]byte{make([]byte, 32)}, 12*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager.Name = app.cfg.Session.CookieName
+	app.sessionManager = manager
+
+	ts := newTestServer(t, app.routes())
+
+	// Signing up redirects, carrying the flash in the session cookie.
+	code, headers, _ := ts.submitForm(t, "/user/signup", url.Values{
+		"name":             {"Bob"},
+		"email":            {"bob@example.com"},
+		"password":         {"validPa$$word"},
+		"password_confirm": {"validPa$$word"},
+	})
+	if code != http.StatusSeeOther {
+		t.Fatalf("signup: got status %d; want %d", code, http.StatusSeeOther)
+	}
+
+	// The flash shows once on the next page, then clears: popping it
+	// during render must still reach the response's Set-Cookie header.
+	flash := "Your signup was successful. Please log in."
+	_, _, body := ts.get(t, headers.Get("Location"))
+	if !strings.Contains(body, flash) {
+		t.Errorf("login page doesn't contain flash %q", flash)
+	}
+	_, _, body = ts.get(t, headers.Get("Location"))
+	if strings.Contains(body, flash) {
+		t.Error("flash message was not cleared after being shown")
+	}
+
+	// Logging in authenticates later requests via the cookie alone.
+	ts.loginAsMockUser(t)
+
+	code, _, _ = ts.get(t, "/snippet/create")
+	if code != http.StatusOK {
+		t.Errorf("create after login: got status %d; want %d", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/version"
+)
+
+// healthCheckTimeout bounds how long each dependency probe may take, so a
+// hung database doesn't make the health endpoint hang too.
+const healthCheckTimeout = 2 * time.Second
+
+// versionInfo reports which build is running, so operators can confirm a
+// deploy landed.
+func (app *application) versionInfo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(version.Get()); err != nil {
+		app.logger.Error(err.Error())
+	}
+}
+
+// securityTxt serves /.well-known/security.txt per RFC 9116, generated
+// from the security.* settings so the contact and expiry can't go stale
+// in a forgotten static file.
+func (app *application) securityTxt(w http.ResponseWriter, r *http.Request) {
+	sec := app.cfg.Security
+
+	if sec.Contact == "" {
+		app.notFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+
+	fmt.Fprintf(w, "Contact: %s\n", sec.Contact)
+	if sec.Policy != "" {
+		fmt.Fprintf(w, "Policy: %s\n", sec.Policy)
+	}
+	fmt.Fprintf(w, "Expires: %s\n", time.Now().Add(sec.Expiry).UTC().Format(time.RFC3339))
+}
+
+// livez reports that the process is up and able to serve requests. It
+// deliberately checks nothing else: orchestrators use it to decide whether
+// to restart the process, not whether to route traffic to it.
+func (app *application) livez(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// readyz reports whether the instance should receive traffic: the database
+// is reachable, the schema is present, and warm-up has finished. During
+// graceful shutdown it fails immediately so orchestrators drain traffic
+// before the listener closes.
+func (app *application) readyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !app.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "shutting down"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	if err := app.db.PingContext(ctx); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "database unreachable"})
+		return
+	}
+
+	// Verify the schema is in place by touching the snippets table.
+	var n int
+	if err := app.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM snippets WHERE 1 = 0").Scan(&n); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "schema not ready"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// healthz reports the health of the application and its dependencies as
+// JSON. It returns 503 Service Unavailable if a critical dependency (the
+// database or session store) is down, which is what load balancers key off.
+func (app *application) healthz(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]string{
+		"database":  "ok",
+		"sessions":  "ok",
+		"templates": "ok",
+	}
+	healthy := true
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	if err := app.db.PingContext(ctx); err != nil {
+		checks["database"] = err.Error()
+		healthy = false
+	}
+
+	var n int
+	if err := app.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sessions").Scan(&n); err != nil {
+		checks["sessions"] = err.Error()
+		healthy = false
+	}
+
+	if len(app.templateCache) == 0 {
+		checks["templates"] = "template cache is empty"
+		healthy = false
+	}
+
+	status := http.StatusOK
+	body := map[string]any{
+		"status":  "ok",
+		"version": version.Get(),
+		"checks":  checks,
+	}
+	if !healthy {
+		status = http.StatusServiceUnavailable
+		body["status"] = "unavailable"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		app.logger.Error(err.Error())
+	}
+}
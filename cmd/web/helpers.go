@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/go-playground/form/v4"
+	"github.com/justinas/nosurf"
+)
+
+// errorReferenceAlphabet is the character set used for error reference
+// IDs. It avoids lowercase so references are unambiguous when read back
+// over the phone.
+const errorReferenceAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// newErrorReference returns a short random reference ID suitable for
+// quoting to users on error pages and grepping out of the logs.
+func newErrorReference() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "UNKNOWN"
+	}
+	for i := range b {
+		b[i] = errorReferenceAlphabet[int(b[i])%len(errorReferenceAlphabet)]
+	}
+	return string(b)
+}
+
+// serverError logs an error message and stack trace under a short random
+// reference ID, then sends a generic 500 Internal Server Error response
+// quoting that reference so support can find the exact log entry. When a
+// Sentry DSN is configured the error is also reported there with the
+// request and user attached; panics recovered by recoverPanic flow through
+// here too.
+func (app *application) serverError(w http.ResponseWriter, r *http.Request, err error) {
+	requestID := app.requestIDFrom(r)
+	reference := newErrorReference()
+
+	app.logger.Error(err.Error(), "reference", reference, "request_id", requestID, "trace", string(debug.Stack()))
+
+	if app.sentryEnabled {
+		sentry.WithScope(func(scope *sentry.Scope) {
+			scope.SetRequest(r)
+			scope.SetTag("reference", reference)
+			scope.SetTag("request_id", requestID)
+			if lc, ok := r.Context().Value(logContextKey).(*logContext); ok && lc.userID != 0 {
+				scope.SetUser(sentry.User{ID: strconv.Itoa(lc.userID)})
+			}
+			sentry.CaptureException(err)
+		})
+	}
+
+	// In debug mode the full error and stack trace go straight into the
+	// response, saving a round-trip to the logs during development.
+	if app.debug {
+		body := fmt.Sprintf("%s\nreference: %s\n\n%s", err, reference, debug.Stack())
+		http.Error(w, body, http.StatusInternalServerError)
+		return
+	}
+
+	app.errorPage(w, r, http.StatusInternalServerError, "500.tmpl", reference)
+}
+
+// requestIDFrom returns the correlation ID assigned to the request by the
+// requestID middleware, or "" if there is none.
+func (app *application) requestIDFrom(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDContextKey).(string)
+	return id
+}
+
+// clientError sends a specific status code and corresponding description to
+// the user.
+func (app *application) clientError(w http.ResponseWriter, status int) {
+	http.Error(w, http.StatusText(status), status)
+}
+
+// notFound sends a 404 Not Found response rendered from the 404 page
+// template.
+func (app *application) notFound(w http.ResponseWriter, r *http.Request) {
+	app.errorPage(w, r, http.StatusNotFound, "404.tmpl", "")
+}
+
+// errorPage renders one of the error page templates. It cannot assume
+// the session or CSRF middleware ran — errors happen on API routes too —
+// so the template data is built from the request alone rather than
+// through newTemplateData. If rendering the page fails as well, it
+// falls back to a plain-text response.
+func (app *application) errorPage(w http.ResponseWriter, r *http.Request, status int, page, reference string) {
+	fallback := http.StatusText(status)
+	if reference != "" {
+		fallback = fmt.Sprintf("Something went wrong — reference %s", reference)
+	}
+
+	ts, ok := app.templateCache[page]
+	if !ok {
+		http.Error(w, fallback, status)
+		return
+	}
+
+	data := &templateData{
+		CurrentYear:    time.Now().Year(),
+		SiteName:       app.siteSettings().SiteName,
+		Locale:         app.translator.Match(r.Header.Get("Accept-Language")),
+		Locales:        app.translator.Locales(),
+		Clock:          clock{loc: time.UTC, layout: app.translator.T("en", "format.datetime")},
+		Timezone:       app.cfg.Server.Timezone,
+		Timezones:      commonTimezones,
+		Theme:          themeDefault,
+		Themes:         themes,
+		CSRFToken:      nosurf.Token(r),
+		ErrorReference: reference,
+	}
+
+	buf := new(bytes.Buffer)
+
+	if err := ts.ExecuteTemplate(buf, "base", data); err != nil {
+		app.logger.Error(err.Error())
+		http.Error(w, fallback, status)
+		return
+	}
+
+	w.WriteHeader(status)
+
+	buf.WriteTo(w)
+}
+
+// methodNotAllowed sends a 405 Method Not Allowed response. The router
+// has already set the Allow header to the methods the route does accept,
+// so it is echoed into the body; clients that ask for JSON get a
+// structured one.
+func (app *application) methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	allow := w.Header().Get("Allow")
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintf(w, "{\"error\": %q, \"allow\": %q}\n", http.StatusText(http.StatusMethodNotAllowed), allow)
+		return
+	}
+
+	body := http.StatusText(http.StatusMethodNotAllowed)
+	if allow != "" {
+		body = fmt.Sprintf("%s (allowed: %s)", body, allow)
+	}
+	http.Error(w, body, http.StatusMethodNotAllowed)
+}
+
+// render retrieves the appropriate template set from the cache, writes it to
+// a buffer to catch any runtime errors, and then writes out the response.
+func (app *application) render(w http.ResponseWriter, r *http.Request, status int, page string, data *templateData) {
+	ts, ok := app.templateCache[page]
+	if !ok {
+		err := fmt.Errorf("the template %s does not exist", page)
+		app.serverError(w, r, err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+
+	start := time.Now()
+
+	err := ts.ExecuteTemplate(buf, "base", data)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.metrics.ObserveRender(page, time.Since(start))
+
+	w.WriteHeader(status)
+
+	buf.WriteTo(w)
+}
+
+// newTemplateData returns a templateData struct initialized with the default
+// data common to every page.
+func (app *application) newTemplateData(r *http.Request) *templateData {
+	return &templateData{
+		CurrentYear:         time.Now().Year(),
+		SiteName:            app.siteSettings().SiteName,
+		CanonicalURL:        app.canonicalURL(r),
+		Locale:              app.locale(r),
+		Locales:             app.translator.Locales(),
+		Clock:               app.clock(r),
+		Timezone:            app.timezone(r),
+		Timezones:           commonTimezones,
+		Theme:               app.theme(r),
+		Themes:              themes,
+		Announcement:        app.announcement(r),
+		Flashes:             decodeFlashes(app.sessionManager.PopString(r.Context(), "flash")),
+		IsAuthenticated:     app.isAuthenticated(r),
+		UnreadNotifications: app.unreadNotifications(r),
+		CSRFToken:           nosurf.Token(r),
+		CSPNonce:            app.cspNonce(r),
+	}
+}
+
+// maxMultipartMemory is how much of a multipart body is held in memory
+// while parsing; file parts beyond it spill to temporary files on disk.
+// The overall body size is still capped by the maxBytes middleware.
+const maxMultipartMemory = 4 << 20
+
+// decodePostForm parses the request form data and decodes it into the dst
+// struct. It panics if dst is not a non-nil pointer. Multipart bodies are
+// parsed too; when dst embeds fileFields the uploaded file headers are
+// delivered alongside the decoded fields. Oversized bodies surface as
+// *http.MaxBytesError or multipart.ErrMessageTooLarge, which
+// formErrorStatus maps to 413.
+func (app *application) decodePostForm(r *http.Request, dst any) error {
+	var err error
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		err = r.ParseMultipartForm(maxMultipartMemory)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.MultipartForm != nil {
+		if fc, ok := dst.(fileCarrier); ok {
+			fc.setFileHeaders(r.MultipartForm.File)
+		}
+	}
+
+	err = app.formDecoder.Decode(dst, r.PostForm)
+	if err != nil {
+		var invalidDecoderError *form.InvalidDecoderError
+
+		if errors.As(err, &invalidDecoderError) {
+			panic(err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// cspNonce returns the per-request nonce minted by secureHeaders, or ""
+// outside its chain.
+func (app *application) cspNonce(r *http.Request) string {
+	nonce, _ := r.Context().Value(cspNonceContextKey).(string)
+	return nonce
+}
+
+// formErrorStatus maps a form decoding error to the right client error
+// status: 413 when the body hit a maxBytes limit or a multipart body
+// exceeded the parser's limits, 400 otherwise.
+func formErrorStatus(err error) int {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) || errors.Is(err, multipart.ErrMessageTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// isAuthenticated returns true if the current request is from an
+// authenticated user.
+func (app *application) isAuthenticated(r *http.Request) bool {
+	return app.sessionManager.Exists(r.Context(), "authenticatedUserID")
+}
+
+// isAdmin returns true if the current request is from an authenticated
+// administrator.
+func (app *application) isAdmin(r *http.Request) bool {
+	id := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+	if id == 0 {
+		return false
+	}
+
+	admin, err := app.users.IsAdmin(id)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return false
+	}
+
+	return admin
+}
+
+// clientIP returns the request's client address. When the connection
+// comes from a trusted proxy the rightmost untrusted entry in
+// X-Forwarded-For (or, failing that, X-Real-IP) is used instead, so
+// logging, rate limiting and audit records all see the real client.
+func (app *application) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !app.fromTrustedProxy(host) {
+		return host
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		parts := strings.Split(xff, ",")
+
+		// Walk right to left past any intermediate trusted proxies; the
+		// first untrusted address is the client. Everything left of it
+		// is client-supplied and must not be believed.
+		for i := len(parts) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(parts[i])
+			if !app.fromTrustedProxy(ip) {
+				return ip
+			}
+		}
+		return strings.TrimSpace(parts[0])
+	}
+
+	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+		return realIP
+	}
+
+	return host
+}
+
+// fromTrustedProxy reports whether the address belongs to one of the
+// configured trusted proxy networks.
+func (app *application) fromTrustedProxy(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range app.trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requestScheme returns the scheme the client used: X-Forwarded-Proto
+// when the request came through a trusted proxy, otherwise derived from
+// the connection itself.
+func (app *application) requestScheme(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if app.fromTrustedProxy(host) {
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+			return proto
+		}
+	}
+
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// path prepends the configured base path to an application-relative URL
+// path, for use in redirects.
+func (app *application) path(p string) string {
+	return app.cfg.Server.BasePath + p
+}
+
+// isLoopback reports whether the request originated from the local
+// machine, taking trusted proxies into account.
+func (app *application) isLoopback(r *http.Request) bool {
+	ip := net.ParseIP(app.clientIP(r))
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+)
+
+// sessionKeyLocale stores the visitor's explicit locale choice.
+const sessionKeyLocale = "locale"
+
+// locale returns the locale for the request: the preference stored in
+// the session if there is one, otherwise the best Accept-Language
+// match.
+func (app *application) locale(r *http.Request) string {
+	if l := app.sessionManager.GetString(r.Context(), sessionKeyLocale); app.translator.Supported(l) {
+		return l
+	}
+
+	return app.translator.Match(r.Header.Get("Accept-Language"))
+}
+
+// t translates the message key for the request's locale.
+func (app *application) t(r *http.Request, key string, args ...any) string {
+	return app.translator.T(app.locale(r), key, args...)
+}
+
+// userLocalePost stores the visitor's preferred locale in their
+// session, overriding Accept-Language detection from then on.
+func (app *application) userLocalePost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, formErrorStatus(err))
+		return
+	}
+
+	locale := r.PostForm.Get("locale")
+	if !app.translator.Supported(locale) {
+		app.clientError(w, http.StatusUnprocessableEntity)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), sessionKeyLocale, locale)
+
+	http.Redirect(w, r, app.path("/"), http.StatusSeeOther)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor number systemd uses when
+// passing sockets: fds 0-2 are stdin/stdout/stderr.
+const listenFDsStart = 3
+
+// activationListener returns a listener for a socket passed by systemd
+// socket activation, or nil if the process was not socket-activated.
+// This lets the service bind privileged ports without running as root:
+// systemd opens the socket and hands it over via the LISTEN_FDS
+// protocol.
+func activationListener() (net.Listener, error) {
+	fds := os.Getenv("LISTEN_FDS")
+	if fds == "" {
+		return nil, nil
+	}
+
+	// LISTEN_PID guards against inherited environments: the variables
+	// are only meant for the exact process systemd started.
+	if pid := os.Getenv("LISTEN_PID"); pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS value %q", fds)
+	}
+	if n > 1 {
+		return nil, fmt.Errorf("expected 1 activated socket, got %d", n)
+	}
+
+	// Clear the variables so child processes don't mistake the fds for
+	// their own.
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_PID")
+
+	f := os.NewFile(uintptr(listenFDsStart), "LISTEN_FD_3")
+	defer f.Close()
+
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("activated socket is not a stream listener: %w", err)
+	}
+
+	return ln, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// loadtestResult is one request's outcome, recorded by a worker.
+type loadtestResult struct {
+	path     string
+	duration time.Duration
+	failed   bool
+}
+
+// loadtestCmd hammers a running instance with concurrent GET requests and
+// reports latency percentiles and error rates per path, so the cost of a
+// new middleware or handler change can be measured before and after. It
+// only reads; run it against a disposable instance anyway, since the
+// request volume is deliberately unfriendly.
+func loadtestCmd(args []string) {
+	fs := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	target := fs.String("target", "https://localhost:4000", "base URL of the running instance")
+	paths := fs.String("paths", "/", "comma-separated request paths")
+	concurrency := fs.Int("concurrency", 10, "number of concurrent workers")
+	duration := fs.Duration("duration", 10*time.Second, "how long to run")
+	insecure := fs.Bool("insecure", false, "skip TLS certificate verification (for self-signed dev certs)")
+	fs.Parse(args)
+
+	var pathList []string
+	for _, p := range strings.Split(*paths, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			pathList = append(pathList, p)
+		}
+	}
+	if len(pathList) == 0 || *concurrency < 1 {
+		fmt.Fprintln(os.Stderr, "loadtest: need at least one path and one worker")
+		os.Exit(2)
+	}
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: *insecure},
+			MaxIdleConnsPerHost: *concurrency,
+		},
+	}
+
+	fmt.Printf("Running %d workers against %s for %s...\n", *concurrency, *target, *duration)
+
+	deadline := time.Now().Add(*duration)
+	results := make(chan loadtestResult, 1024)
+
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for n := worker; time.Now().Before(deadline); n++ {
+				path := pathList[n%len(pathList)]
+
+				start := time.Now()
+				resp, err := client.Get(*target + path)
+				elapsed := time.Since(start)
+
+				failed := err != nil
+				if resp != nil {
+					resp.Body.Close()
+					failed = failed || resp.StatusCode >= 400
+				}
+
+				results <- loadtestResult{path: path, duration: elapsed, failed: failed}
+			}
+		}(i)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	byPath := map[string][]loadtestResult{}
+	for res := range results {
+		byPath[res.path] = append(byPath[res.path], res)
+	}
+
+	fmt.Printf("\n%-30s %8s %8s %9s %9s %9s %9s\n", "PATH", "REQS", "ERRORS", "P50", "P90", "P99", "MAX")
+	total := 0
+	for _, path := range pathList {
+		rs := byPath[path]
+		total += len(rs)
+
+		durations := make([]time.Duration, 0, len(rs))
+		errors := 0
+		for _, res := range rs {
+			durations = append(durations, res.duration)
+			if res.failed {
+				errors++
+			}
+		}
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+		fmt.Printf("%-30s %8d %8d %9s %9s %9s %9s\n", path, len(rs), errors,
+			percentile(durations, 50), percentile(durations, 90),
+			percentile(durations, 99), percentile(durations, 100))
+	}
+	fmt.Printf("\n%d requests in %s (%.0f req/s)\n", total, *duration, float64(total)/duration.Seconds())
+}
+
+// percentile returns the p-th percentile of the sorted durations, rounded
+// for display.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := len(sorted) * p / 100
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i].Round(100 * time.Microsecond)
+}
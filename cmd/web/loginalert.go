@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/models"
+)
+
+// alertNewDevice records a successful login's IP and user-agent
+// combination and emails a security alert when the account has not used
+// it before. The account's very first recorded combination doesn't
+// alert, so existing users don't all get an email on their first login
+// after rollout. Users can turn the alerts off via the security event's
+// email toggle on the notification preferences page. Failures are
+// logged, never surfaced — the login itself succeeded.
+func (app *application) alertNewDevice(r *http.Request, userID int) {
+	ip := app.clientIP(r)
+
+	known, first, err := app.loginDevices.Record(userID, ip, r.UserAgent())
+	if err != nil {
+		app.logger.Error(err.Error())
+		return
+	}
+	if known || first {
+		return
+	}
+
+	app.logger.Info("login from new device", "user_id", userID, "ip", ip)
+
+	if app.mailer == nil {
+		return
+	}
+
+	prefs, err := app.notifications.Prefs(userID)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return
+	}
+	if pref, ok := prefs[models.NotificationSecurity]; ok && !pref.Email {
+		return
+	}
+
+	user, err := app.users.Get(userID)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return
+	}
+
+	app.sendEmail(user.Email, "login_alert.tmpl", map[string]any{
+		"Name":       user.Name,
+		"Time":       time.Now().UTC().Format("02 Jan 2006 at 15:04 MST"),
+		"IP":         ip,
+		"UserAgent":  r.UserAgent(),
+		"ContactURL": app.absoluteURL(r, "/contact"),
+	})
+}
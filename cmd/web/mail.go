@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"snippetbox.ayubasayyed.net/internal/mailer"
+)
+
+// emailTaskKind is the task queue kind for outgoing email.
+const emailTaskKind = "email"
+
+// emailTask is the queued payload for one outgoing email.
+type emailTask struct {
+	Recipient    string         `json:"recipient"`
+	TemplateFile string         `json:"template_file"`
+	Data         map[string]any `json:"data"`
+}
+
+// sendEmail queues an email for background delivery, so a slow or down
+// mail provider never fails the request that triggered the email. The
+// queue retries failed sends with backoff and dead-letters them once
+// the attempts run out. With no mailer configured it is a no-op.
+func (app *application) sendEmail(recipient, templateFile string, data map[string]any) {
+	if app.mailer == nil {
+		return
+	}
+
+	err := app.taskQueue.Enqueue(emailTaskKind, emailTask{
+		Recipient:    recipient,
+		TemplateFile: templateFile,
+		Data:         data,
+	})
+	if err != nil {
+		app.logger.Error(err.Error())
+	}
+}
+
+// handleEmailTask delivers one queued email. Errors go back to the
+// queue, which retries the task.
+func (app *application) handleEmailTask(payload []byte) error {
+	var t emailTask
+	if err := json.Unmarshal(payload, &t); err != nil {
+		return err
+	}
+
+	return app.mailer.Send(t.Recipient, t.TemplateFile, t.Data)
+}
+
+// sampleEmailData returns sample data for one email template, mirroring
+// what the real senders pass, so the dev preview renders something
+// representative.
+func sampleEmailData(templateFile string) map[string]any {
+	switch templateFile {
+	case "contact_message.tmpl":
+		return map[string]any{
+			"Name":    "Alice",
+			"Email":   "alice@example.com",
+			"Message": "Hello there — just trying out the contact form.",
+		}
+	case "login_alert.tmpl":
+		return map[string]any{
+			"Name":       "Alice",
+			"Time":       "28 Aug 2026 at 12:00 UTC",
+			"IP":         "203.0.113.7",
+			"Location":   "Berlin, Germany",
+			"UserAgent":  "Mozilla/5.0 (X11; Linux x86_64)",
+			"ContactURL": "https://example.com/contact",
+		}
+	case "weekly_digest.tmpl":
+		return map[string]any{
+			"Name": "Alice",
+			"Week": "2026-W35",
+			"Snippets": []map[string]any{
+				{"Title": "An old silent pond", "Copies": 12, "URL": "https://example.com/snippet/view/1"},
+				{"Title": "Over the wintry forest", "Copies": 4, "URL": "https://example.com/snippet/view/2"},
+			},
+		}
+	}
+	return map[string]any{}
+}
+
+// devMailIndex lists the email templates available for preview. It is
+// only routed in debug mode.
+func (app *application) devMailIndex(w http.ResponseWriter, r *http.Request) {
+	names, err := mailer.Templates()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	fmt.Fprintf(w, "<h1>Email templates</h1>\n<ul>\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "<li><a href='%s'>%s</a></li>\n", app.path("/dev/mail/"+name), html.EscapeString(name))
+	}
+	fmt.Fprintf(w, "</ul>\n")
+}
+
+// devMailPreview renders one email template with sample data: the
+// subject, the plain part and the HTML part (in an iframe, so its
+// markup stays isolated from the preview page). ?part=html serves the
+// raw HTML part for that iframe. It is only routed in debug mode.
+func (app *application) devMailPreview(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+	name := params.ByName("template")
+
+	names, err := mailer.Templates()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	known := false
+	for _, n := range names {
+		known = known || n == name
+	}
+	if !known {
+		app.notFound(w, r)
+		return
+	}
+
+	subject, plainBody, htmlBody, err := mailer.Render(name, sampleEmailData(name))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if r.URL.Query().Get("part") == "html" {
+		w.Write(htmlBody)
+		return
+	}
+
+	fmt.Fprintf(w, "<h1>%s</h1>\n", html.EscapeString(name))
+	fmt.Fprintf(w, "<p><strong>Subject:</strong> %s</p>\n", html.EscapeString(subject))
+	fmt.Fprintf(w, "<h2>Plain part</h2>\n<pre>%s</pre>\n", html.EscapeString(string(plainBody)))
+	fmt.Fprintf(w, "<h2>HTML part</h2>\n<iframe src='%s?part=html' width='100%%' height='600'></iframe>\n", app.path("/dev/mail/"+name))
+}
@@ -0,0 +1,781 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"log/slog"
+	"log/syslog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/cookiesession"
+	"snippetbox.ayubasayyed.net/internal/feed"
+	"snippetbox.ayubasayyed.net/internal/i18n"
+	"snippetbox.ayubasayyed.net/internal/jobs"
+	"snippetbox.ayubasayyed.net/internal/mailer"
+	"snippetbox.ayubasayyed.net/internal/metrics"
+	"snippetbox.ayubasayyed.net/internal/migrations"
+	"snippetbox.ayubasayyed.net/internal/models"
+	"snippetbox.ayubasayyed.net/internal/ratelimit"
+	"snippetbox.ayubasayyed.net/internal/tasks"
+	"snippetbox.ayubasayyed.net/internal/version"
+
+	"github.com/alexedwards/scs/mysqlstore"
+	"github.com/alexedwards/scs/redisstore"
+	"github.com/alexedwards/scs/sqlite3store"
+	"github.com/alexedwards/scs/v2"
+	"github.com/alexedwards/scs/v2/memstore"
+	"github.com/getsentry/sentry-go"
+	"github.com/go-playground/form/v4"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gomodule/redigo/redis"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// sessionManager abstracts the session operations the application uses,
+// so the stateless cookie backend can stand in for scs.
+type sessionManager interface {
+	LoadAndSave(next http.Handler) http.Handler
+	Put(ctx context.Context, key string, val any)
+	PopString(ctx context.Context, key string) string
+	GetString(ctx context.Context, key string) string
+	GetInt(ctx context.Context, key string) int
+	Exists(ctx context.Context, key string) bool
+	Remove(ctx context.Context, key string)
+	RenewToken(ctx context.Context) error
+}
+
+// application holds the application-wide dependencies for the web
+// application.
+type application struct {
+	cfg            config
+	logger         *slog.Logger
+	logLevel       *slog.LevelVar
+	accessLog      *log.Logger
+	db             *sql.DB
+	snippets       models.SnippetStore
+	users          models.UserStore
+	comments       models.CommentStore
+	notifications  models.NotificationStore
+	reactions      models.ReactionStore
+	contactMsgs    models.ContactStore
+	loginDevices   models.LoginDeviceStore
+	mailer         *mailer.Mailer
+	templateCache  map[string]*template.Template
+	translator     *i18n.Translator
+	formDecoder    *form.Decoder
+	sessionManager sessionManager
+	firehose       *feed.Hub
+	commentFeed    *feed.CommentHub
+	scheduler      *jobs.Scheduler
+	taskQueue      *tasks.Queue
+	metrics        *metrics.Metrics
+	stats          *statusCounters
+	trustedProxies []*net.IPNet
+	limiter        *ratelimit.Limiter
+	ipFilter       *ipFilter
+	settings       *settingsService
+	settingStore   *models.SettingModel
+	cspLimiter     *ratelimit.Limiter
+	cspReports     *cspReportLog
+	previewLimiter *ratelimit.Limiter
+	contactLimiter *ratelimit.Limiter
+	suggestions    *suggestCache
+	pages          map[string]staticPage
+	copyCounts     *copyCounter
+	postLimiter    *ratelimit.Limiter
+	limitExempt    []*net.IPNet
+	sentryEnabled  bool
+	debug          bool
+	ready          atomic.Bool
+}
+
+func main() {
+	args := os.Args[1:]
+
+	// The first argument selects a subcommand; invocations that start
+	// with a flag (or nothing) run the server, preserving the CLI from
+	// before subcommands existed.
+	cmd := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "serve":
+		serveCmd(args)
+	case "migrate":
+		migrateCmd(args)
+	case "createadmin":
+		createadminCmd(args)
+	case "check-templates":
+		checkTemplatesCmd()
+	case "seed":
+		seedCmd(args)
+	case "purge":
+		purgeCmd(args)
+	case "loadtest":
+		loadtestCmd(args)
+	case "help":
+		usage(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", cmd)
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+}
+
+// usage lists the available subcommands. Each command documents its own
+// flags via -h.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, `Usage: web <command> [flags]
+
+Commands:
+  serve        Run the web server (the default when no command is given)
+  migrate      Run database migrations: migrate [flags] up|down|status
+  createadmin  Create or promote an admin user: createadmin -email ... -name ...
+  check-templates  Parse and execute every page template with sample data
+  seed         Insert example snippets into the database
+  purge        Delete expired snippets and session rows
+  loadtest     Send concurrent requests at a running instance and report latencies
+  help         Show this message`)
+}
+
+// newLogger builds the structured logger described by the log settings.
+// The returned LevelVar holds the minimum level so it can be adjusted at
+// runtime through the admin endpoint.
+func newLogger(cfg config) (*slog.Logger, *slog.LevelVar, error) {
+	// validate has already accepted the level, so the error can't occur.
+	level, _ := parseLogLevel(cfg.Log.Level)
+
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(level)
+
+	handlerOptions := &slog.HandlerOptions{Level: levelVar}
+
+	var handler slog.Handler
+	switch cfg.Log.Output {
+	case "syslog":
+		sw, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "snippetbox")
+		if err != nil {
+			return nil, nil, err
+		}
+		handler = newSyslogHandler(sw, levelVar)
+	default:
+		if cfg.Log.Format == "json" {
+			handler = slog.NewJSONHandler(os.Stdout, handlerOptions)
+		} else {
+			handler = slog.NewTextHandler(os.Stdout, handlerOptions)
+		}
+	}
+
+	return slog.New(handler), levelVar, nil
+}
+
+// serveCmd runs the web server.
+func serveCmd(args []string) {
+	cfg, _ := parseConfig(args, nil)
+
+	logger, levelVar, err := newLogger(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var accessLog *log.Logger
+	if cfg.Log.AccessLog != "" {
+		f, err := os.OpenFile(cfg.Log.AccessLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		defer f.Close()
+
+		accessLog = log.New(f, "", 0)
+	}
+
+	if cfg.Sentry.DSN != "" {
+		err := sentry.Init(sentry.ClientOptions{
+			Dsn:              cfg.Sentry.DSN,
+			ServerName:       "snippetbox",
+			Release:          version.Get().Version,
+			AttachStacktrace: true,
+		})
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		defer sentry.Flush(2 * time.Second)
+	}
+
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	// SQLite databases are migrated automatically on startup, so local
+	// development needs no provisioning.
+	if cfg.DB.Driver == "sqlite3" {
+		if _, err := migrations.Up(db, cfg.DB.Driver); err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	// When a read replica is configured, open a second pool for it. The
+	// models fall back to the primary if a replica query fails, so the
+	// replica going away degrades performance rather than availability.
+	var readDB *sql.DB
+	if cfg.DB.ReadDSN != "" {
+		readCfg := cfg.DB
+		readCfg.DSN = cfg.DB.ReadDSN
+
+		readDB, err = openDB(readCfg)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		defer readDB.Close()
+	}
+
+	translator, err := i18n.New()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	templateCache, err := newTemplateCache(cfg.Server.BasePath, cfg.Server.AssetURL, translator)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	pages, err := loadStaticPages()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	formDecoder := form.NewDecoder()
+
+	var sessions sessionManager
+	switch cfg.Session.Store {
+	case "cookie":
+		// Stateless sessions live entirely in an encrypted cookie, so
+		// instances need no shared storage at all.
+		keys, err := parseSessionKeys(cfg.Session.Keys)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+
+		manager, err := cookiesession.New(keys, cfg.Session.Lifetime)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		manager.OnError = func(err error) {
+			logger.Error("session save failed", "error", err)
+		}
+		manager.Insecure = !cfg.Server.TLS
+		manager.Name = cfg.Session.CookieName
+		manager.Domain = cfg.Session.CookieDomain
+		manager.Path = cfg.Session.CookiePath
+		manager.SameSite, _ = parseSameSite(cfg.Session.SameSite)
+		sessions = manager
+	default:
+		sessions = newSCSManager(cfg, db)
+	}
+
+	firehose := feed.NewHub()
+	go firehose.Run()
+
+	commentFeed := feed.NewCommentHub()
+
+	m := metrics.New()
+
+	// Expose connection pool statistics so pool exhaustion shows up in
+	// monitoring before users notice it.
+	m.RegisterGaugeFunc("snippetbox_db_open_connections", "Open connections in the pool.", func() float64 {
+		return float64(db.Stats().OpenConnections)
+	})
+	m.RegisterGaugeFunc("snippetbox_db_in_use_connections", "Connections currently in use.", func() float64 {
+		return float64(db.Stats().InUse)
+	})
+	m.RegisterGaugeFunc("snippetbox_db_idle_connections", "Idle connections in the pool.", func() float64 {
+		return float64(db.Stats().Idle)
+	})
+	m.RegisterGaugeFunc("snippetbox_db_wait_count_total", "Total number of times a connection was waited for.", func() float64 {
+		return float64(db.Stats().WaitCount)
+	})
+	m.RegisterGaugeFunc("snippetbox_db_wait_duration_seconds_total", "Total time blocked waiting for a connection.", func() float64 {
+		return db.Stats().WaitDuration.Seconds()
+	})
+
+	// Expose the number of rows in the session store so operators can
+	// watch for runaway session growth. Only meaningful for the database
+	// store.
+	if cfg.Session.Store == "db" {
+		m.RegisterGaugeFunc("snippetbox_sessions_total", "Number of rows in the session store.", func() float64 {
+			var n float64
+			if err := db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&n); err != nil {
+				return -1
+			}
+			return n
+		})
+	}
+
+	// onQuery feeds every model query into the metrics histograms and logs
+	// any query over the slow threshold with its arguments redacted.
+	// validate has already checked the CIDRs, so the error can't occur.
+	trustedProxies, _ := parseCIDRs(cfg.Server.TrustedProxies)
+
+	onQuery := func(name string, d time.Duration, args []any) {
+		m.ObserveQuery(name, d)
+
+		if cfg.DB.SlowQueryThreshold > 0 && d >= cfg.DB.SlowQueryThreshold {
+			logger.Warn("slow query", "query", name, "duration", d, "args", models.RedactArgs(args))
+		}
+	}
+
+	// The mailer stays nil until the selected provider has its
+	// configuration; callers treat a nil mailer as "email disabled".
+	var mailClient *mailer.Mailer
+	if cfg.mailEnabled() {
+		switch cfg.Mail.Provider {
+		case "ses":
+			mailClient = mailer.NewSES(cfg.Mail.Region, cfg.Mail.AccessKey, cfg.Mail.SecretKey, cfg.Mail.Sender)
+		case "sendgrid":
+			mailClient = mailer.NewSendGrid(cfg.Mail.APIKey, cfg.Mail.Sender)
+		case "mailgun":
+			mailClient = mailer.NewMailgun(cfg.Mail.Domain, cfg.Mail.APIKey, cfg.Mail.Sender)
+		default:
+			mailClient = mailer.NewSMTP(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.Mail.Sender, cfg.SMTP.TLS)
+		}
+	}
+
+	app := &application{
+		cfg:            cfg,
+		logger:         logger,
+		logLevel:       levelVar,
+		accessLog:      accessLog,
+		db:             db,
+		snippets:       &models.SnippetModel{DB: db, ReadDB: readDB, OnQuery: onQuery},
+		users:          &models.UserModel{DB: db, OnQuery: onQuery},
+		comments:       &models.CommentModel{DB: db, ReadDB: readDB, OnQuery: onQuery},
+		notifications:  &models.NotificationModel{DB: db, ReadDB: readDB, OnQuery: onQuery},
+		reactions:      &models.ReactionModel{DB: db, ReadDB: readDB, OnQuery: onQuery},
+		contactMsgs:    &models.ContactModel{DB: db, OnQuery: onQuery},
+		loginDevices:   &models.LoginDeviceModel{DB: db, OnQuery: onQuery},
+		mailer:         mailClient,
+		templateCache:  templateCache,
+		translator:     translator,
+		formDecoder:    formDecoder,
+		sessionManager: sessions,
+		firehose:       firehose,
+		commentFeed:    commentFeed,
+		scheduler:      jobs.NewScheduler(),
+		taskQueue:      tasks.NewQueue(db, cfg.Tasks.Workers),
+		metrics:        m,
+		stats:          newStatusCounters(),
+		trustedProxies: trustedProxies,
+		ipFilter:       &ipFilter{},
+		cspLimiter:     ratelimit.New(1, 5),
+		cspReports:     &cspReportLog{},
+		previewLimiter: ratelimit.New(5, 10),
+		contactLimiter: ratelimit.New(0.1, 3),
+		suggestions:    &suggestCache{},
+		pages:          pages,
+		copyCounts:     &copyCounter{},
+		sentryEnabled:  cfg.Sentry.DSN != "",
+		debug:          cfg.Server.Debug,
+	}
+
+	// validate has already checked the CIDR lists, so set cannot fail.
+	app.ipFilter.set(cfg.Access.Allow, cfg.Access.Block)
+
+	app.settingStore = &models.SettingModel{DB: db, OnQuery: onQuery}
+
+	app.settings, err = newSettingsService(app.settingStore)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	// The rate limiter is off unless limit.rps is set; the stricter POST
+	// bucket falls back to the general one when not configured.
+	if cfg.Limit.RPS > 0 {
+		app.limiter = ratelimit.New(cfg.Limit.RPS, cfg.Limit.Burst)
+		app.postLimiter = app.limiter
+		if cfg.Limit.PostRPS > 0 {
+			app.postLimiter = ratelimit.New(cfg.Limit.PostRPS, cfg.Limit.PostBurst)
+		}
+		app.limitExempt, _ = parseCIDRs(cfg.Limit.Exempt)
+	}
+
+	// The policy flags were validated by parseConfig, so these parses
+	// cannot fail here.
+	tlsMinVersion, _ := parseTLSMinVersion(cfg.Server.TLSMinVersion)
+	tlsCurves, _ := parseTLSCurves(cfg.Server.TLSCurves)
+	tlsCiphers, _ := parseTLSCiphers(cfg.Server.TLSCiphers)
+
+	tlsConfig := &tls.Config{
+		MinVersion:       tlsMinVersion,
+		CurvePreferences: tlsCurves,
+		CipherSuites:     tlsCiphers,
+	}
+
+	if cfg.Server.TLS {
+		ciphers := cfg.Server.TLSCiphers
+		if ciphers == "" {
+			ciphers = "defaults"
+		}
+		logger.Info("tls policy", "min_version", cfg.Server.TLSMinVersion, "curves", cfg.Server.TLSCurves, "ciphers", ciphers)
+	}
+
+	// With -autocert, certificates come from Let's Encrypt instead of
+	// the tls-cert/tls-key files: the manager answers TLS-ALPN
+	// challenges on the main listener and HTTP-01 challenges on :80.
+	if cfg.Server.Autocert {
+		certManager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(cfg.Server.AutocertCache),
+			HostPolicy: autocert.HostWhitelist(strings.Split(cfg.Server.AutocertHosts, ",")...),
+		}
+		tlsConfig.GetCertificate = certManager.GetCertificate
+		tlsConfig.NextProtos = append(tlsConfig.NextProtos, "h2", "http/1.1", "acme-tls/1")
+
+		go func() {
+			err := http.ListenAndServe(":80", certManager.HTTPHandler(nil))
+			logger.Error(err.Error())
+		}()
+	}
+
+	// With file-based certificates the pair is served through a reloader
+	// so a SIGHUP picks up renewals without dropping connections.
+	var certs *certReloader
+	if cfg.Server.TLS && !cfg.Server.Autocert {
+		certs, err = newCertReloader(cfg.Server.TLSCert, cfg.Server.TLSKey)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		tlsConfig.GetCertificate = certs.GetCertificate
+
+		go func() {
+			hup := make(chan os.Signal, 1)
+			signal.Notify(hup, syscall.SIGHUP)
+			for range hup {
+				if err := certs.Reload(); err != nil {
+					logger.Error("tls certificate reload failed", "error", err)
+					continue
+				}
+				logger.Info("tls certificates reloaded", "cert", cfg.Server.TLSCert)
+			}
+		}()
+	}
+
+	// -addr may list several addresses: the first serves the
+	// application, the rest serve only the internal operational
+	// endpoints over plain HTTP.
+	addrs := strings.Split(cfg.Server.Addr, ",")
+	for i := range addrs {
+		addrs[i] = strings.TrimSpace(addrs[i])
+	}
+
+	srv := &http.Server{
+		Addr:         addrs[0],
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		Handler:      app.routes(),
+		TLSConfig:    tlsConfig,
+		IdleTimeout:  time.Minute,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	// Background maintenance runs on the scheduler, which the shutdown
+	// goroutine stops cleanly.
+	app.scheduler.OnError = func(name string, err error) {
+		logger.Error("job failed", "job", name, "error", err)
+	}
+	if cfg.Purge.Interval > 0 {
+		app.scheduler.Register("purge", cfg.Purge.Interval, app.runPurge)
+	}
+	app.scheduler.Register("copy-flush", copyFlushInterval, app.runCopyFlush)
+	app.scheduler.Register("digest", digestCheckInterval, app.runDigest)
+	app.scheduler.Start()
+
+	// The task queue runs slow work (emails, webhooks) outside the
+	// request path, with failed tasks retried and then dead-lettered.
+	app.taskQueue.OnError = func(kind string, err error, permanent bool) {
+		if permanent {
+			logger.Error("task dead-lettered after repeated failures", "task", kind, "error", err)
+		} else {
+			logger.Warn("task attempt failed", "task", kind, "error", err)
+		}
+	}
+	app.taskQueue.Handle(emailTaskKind, app.handleEmailTask)
+	if cfg.Tasks.Workers > 0 {
+		app.taskQueue.Start()
+	}
+
+	var internalSrvs []*http.Server
+	for _, a := range addrs[1:] {
+		isrv := &http.Server{
+			Addr:         a,
+			ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+			Handler:      app.internalRoutes(),
+			IdleTimeout:  time.Minute,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		}
+		internalSrvs = append(internalSrvs, isrv)
+
+		go func(isrv *http.Server) {
+			logger.Info("starting internal listener", "addr", isrv.Addr)
+			err := isrv.ListenAndServe()
+			if !errors.Is(err, http.ErrServerClosed) {
+				logger.Error(err.Error())
+			}
+		}(isrv)
+	}
+
+	// Shut down gracefully on SIGINT/SIGTERM, closing the comment feed
+	// first so that any open event streams return and stop blocking
+	// Shutdown.
+	shutdownError := make(chan error)
+
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		<-quit
+
+		logger.Info("shutting down server")
+
+		// Fail readiness first so orchestrators stop routing new
+		// traffic while in-flight requests drain.
+		app.ready.Store(false)
+
+		app.scheduler.Stop()
+
+		// Flush any copy counts batched since the last scheduled run.
+		if err := app.runCopyFlush(); err != nil {
+			logger.Error(err.Error())
+		}
+
+		app.cspLimiter.Stop()
+		app.previewLimiter.Stop()
+		app.contactLimiter.Stop()
+		if app.limiter != nil {
+			app.limiter.Stop()
+			if app.postLimiter != app.limiter {
+				app.postLimiter.Stop()
+			}
+		}
+		if cfg.Tasks.Workers > 0 {
+			app.taskQueue.Stop()
+		}
+		commentFeed.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		for _, isrv := range internalSrvs {
+			isrv.Shutdown(ctx)
+		}
+
+		shutdownError <- srv.Shutdown(ctx)
+	}()
+
+	app.ready.Store(true)
+
+	// Under systemd socket activation the listener arrives as an open
+	// file descriptor instead of being bound here, so privileged ports
+	// work without root. Otherwise bind -addr as usual.
+	ln, err := activationListener()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	build := version.Get()
+	addr := addrs[0]
+	if ln != nil {
+		addr = ln.Addr().String()
+	}
+	logger.Info("starting server", "addr", addr, "version", build.Version, "commit", build.Commit, "build_time", build.BuildTime)
+
+	switch {
+	case !cfg.Server.TLS && ln != nil:
+		err = srv.Serve(ln)
+	case !cfg.Server.TLS:
+		// Plain HTTP, for deployments where nginx, Caddy or a load
+		// balancer terminates TLS in front of the app.
+		err = srv.ListenAndServe()
+	case cfg.Server.Autocert && ln != nil:
+		err = srv.ServeTLS(ln, "", "")
+	case cfg.Server.Autocert:
+		err = srv.ListenAndServeTLS("", "")
+	case ln != nil:
+		// The certificate comes from tlsConfig.GetCertificate, so no
+		// file paths are passed here.
+		err = srv.ServeTLS(ln, "", "")
+	default:
+		err = srv.ListenAndServeTLS("", "")
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	err = <-shutdownError
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	logger.Info("server stopped")
+}
+
+// newSCSManager builds the scs session manager for the server-side
+// session stores (database, redis or memory).
+func newSCSManager(cfg config, db *sql.DB) *scs.SessionManager {
+	manager := scs.New()
+
+	switch cfg.Session.Store {
+	case "memory":
+		// In-memory sessions need no sessions table, which is handy for
+		// local development; they are lost on restart and not shared
+		// between instances.
+		manager.Store = memstore.New()
+	case "redis":
+		// A redis-backed store keeps multi-instance deployments from
+		// hitting the database for every request's session load/save.
+		pool := &redis.Pool{
+			MaxIdle: 10,
+			Dial: func() (redis.Conn, error) {
+				var opts []redis.DialOption
+				if cfg.Session.RedisPassword != "" {
+					opts = append(opts, redis.DialPassword(cfg.Session.RedisPassword))
+				}
+				if cfg.Session.RedisTLS {
+					opts = append(opts, redis.DialUseTLS(true))
+				}
+				return redis.Dial("tcp", cfg.Session.RedisAddr, opts...)
+			},
+		}
+		manager.Store = redisstore.New(pool)
+	default:
+		if cfg.DB.Driver == "sqlite3" {
+			manager.Store = sqlite3store.New(db)
+		} else {
+			manager.Store = mysqlstore.New(db)
+		}
+	}
+
+	manager.Lifetime = cfg.Session.Lifetime
+
+	manager.Cookie.Name = cfg.Session.CookieName
+	manager.Cookie.Domain = cfg.Session.CookieDomain
+	manager.Cookie.Path = cfg.Session.CookiePath
+
+	// validate has already accepted the mode, so the error can't occur.
+	manager.Cookie.SameSite, _ = parseSameSite(cfg.Session.SameSite)
+
+	// Secure cookies only work over HTTPS; behind a TLS-terminating
+	// proxy the app serves plain HTTP and must not set the attribute.
+	manager.Cookie.Secure = cfg.Server.TLS
+
+	return manager
+}
+
+// openDB opens a sql.DB connection pool for the configured driver and DSN,
+// applies the pool tuning settings and verifies the connection with a
+// ping. For SQLite the schema is bootstrapped on first run, so local
+// development needs no provisioning.
+func openDB(cfg dbConfig) (*sql.DB, error) {
+	dsn := cfg.DSN
+
+	if cfg.Driver == "mysql" && (cfg.TLSCA != "" || cfg.TLSCert != "") {
+		var err error
+		dsn, err = registerMySQLTLS(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := sql.Open(cfg.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err = db.Ping(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// registerMySQLTLS builds a TLS configuration from the db.tls_* settings,
+// registers it with the mysql driver and returns the DSN rewritten to use
+// it, so connections to managed database services are encrypted.
+func registerMySQLTLS(cfg dbConfig) (string, error) {
+	tlsConfig := &tls.Config{
+		ServerName: cfg.TLSServerName,
+	}
+
+	if cfg.TLSCA != "" {
+		pem, err := os.ReadFile(cfg.TLSCA)
+		if err != nil {
+			return "", err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return "", fmt.Errorf("no certificates found in %s", cfg.TLSCA)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			return "", err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if err := mysql.RegisterTLSConfig("snippetbox", tlsConfig); err != nil {
+		return "", err
+	}
+
+	// Rewrite the DSN to reference the registered config rather than
+	// requiring operators to add tls=snippetbox by hand.
+	dsnCfg, err := mysql.ParseDSN(cfg.DSN)
+	if err != nil {
+		return "", err
+	}
+	dsnCfg.TLSConfig = "snippetbox"
+
+	return dsnCfg.FormatDSN(), nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"snippetbox.ayubasayyed.net/internal/models"
+)
+
+// mentionRX matches @name tokens in comment bodies. Display names
+// containing spaces or punctuation can't be mentioned this way.
+var mentionRX = regexp.MustCompile(`@(\w+)`)
+
+// maxMentions caps how many users one comment can mention, so a single
+// comment can't be used to spam notifications across the user base.
+const maxMentions = 5
+
+// extractMentions returns the distinct names mentioned in body, lower-cased
+// in order of first appearance, up to maxMentions.
+func extractMentions(body string) []string {
+	names := []string{}
+	seen := map[string]bool{}
+
+	for _, match := range mentionRX.FindAllStringSubmatch(body, -1) {
+		name := strings.ToLower(match[1])
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		names = append(names, name)
+		if len(names) == maxMentions {
+			break
+		}
+	}
+
+	return names
+}
+
+// mentionTargets resolves every name mentioned across the given comments
+// to a user ID, for linking mentions in the rendered page.
+func (app *application) mentionTargets(comments []*models.Comment) (map[string]int, error) {
+	names := []string{}
+	seen := map[string]bool{}
+
+	for _, c := range comments {
+		for _, name := range extractMentions(c.Body) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	return app.users.ByNames(names)
+}
+
+// notifyMentions records a mention notification for each user the comment
+// mentions, except its own author. Failures are logged by notify; the
+// comment itself has already been saved.
+func (app *application) notifyMentions(comment *models.Comment, actorID int) map[int]bool {
+	notified := map[int]bool{}
+
+	users, err := app.users.ByNames(extractMentions(comment.Body))
+	if err != nil {
+		app.logger.Error(err.Error())
+		return notified
+	}
+
+	for _, userID := range users {
+		if userID == actorID || notified[userID] {
+			continue
+		}
+		notified[userID] = true
+
+		app.notify(userID, models.NotificationMention, comment.Author, comment.SnippetID)
+	}
+
+	return notified
+}
+
+// mentionize returns the template function that renders a comment body,
+// turning @name tokens that resolve to a user into profile links. The
+// body is escaped first, so the result is safe to mark as HTML.
+func mentionize(basePath string) func(users map[string]int, body string) template.HTML {
+	return func(users map[string]int, body string) template.HTML {
+		escaped := html.EscapeString(body)
+
+		linked := mentionRX.ReplaceAllStringFunc(escaped, func(match string) string {
+			id, ok := users[strings.ToLower(match[1:])]
+			if !ok {
+				return match
+			}
+			return fmt.Sprintf("<a class='mention' href='%s/user/view/%d'>%s</a>", basePath, id, match)
+		})
+
+		return template.HTML(linked)
+	}
+}
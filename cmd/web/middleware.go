@@ -0,0 +1,422 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justinas/nosurf"
+)
+
+// statusResponseWriter wraps http.ResponseWriter to record the response
+// status code and the number of body bytes written. It passes Flush and
+// Hijack through to the underlying writer so streaming and WebSocket
+// handlers keep working behind the middleware chain.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (w *statusResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *statusResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hj.Hijack()
+	}
+	return nil, nil, fmt.Errorf("underlying ResponseWriter does not support hijacking")
+}
+
+// Unwrap exposes the underlying writer, so http.ResponseController can
+// reach per-request controls like write deadlines through the wrapper.
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// numericSegment matches path segments consisting solely of digits, so
+// routes with IDs collapse to a single metric label.
+var numericSegment = regexp.MustCompile(`/[0-9]+(/|$)`)
+
+// routeLabel normalizes a request path into a bounded-cardinality label for
+// the request metrics.
+func routeLabel(r *http.Request) string {
+	path := r.URL.Path
+	if len(path) >= 8 && path[:8] == "/static/" {
+		return "/static/*filepath"
+	}
+	return numericSegment.ReplaceAllString(path, "/:id$1")
+}
+
+// requestID ensures every request carries a correlation ID: either the one
+// supplied by a trusted upstream in the X-Request-ID header, or a freshly
+// generated one. The ID is stored in the request context, echoed back in
+// the response header and included in every log line for the request.
+func (app *application) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			b := make([]byte, 8)
+			if _, err := rand.Read(b); err == nil {
+				id = hex.EncodeToString(b)
+			}
+		}
+
+		w.Header().Set("X-Request-ID", id)
+
+		ctx := context.WithValue(r.Context(), requestIDContextKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// trackMetrics records request counts, latencies and the in-flight gauge
+// for every request.
+func (app *application) trackMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		app.metrics.RequestsInFlight.Inc()
+		defer app.metrics.RequestsInFlight.Dec()
+
+		sw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(sw, r)
+
+		app.stats.record(sw.status)
+
+		route := routeLabel(r)
+		app.metrics.RequestsTotal.WithLabelValues(r.Method, route, fmt.Sprint(sw.status)).Inc()
+		app.metrics.RequestDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// rateLimit enforces the per-IP token-bucket limits, with a stricter
+// bucket for state-changing methods. Clients over the limit get a 429
+// with a Retry-After hint; exempt networks and a disabled limiter pass
+// straight through.
+func (app *application) rateLimit(next http.Handler) http.Handler {
+	if app.limiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := app.clientIP(r)
+
+		if app.limitExempted(ip) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		lim := app.limiter
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			lim = app.postLimiter
+		}
+
+		ok, retryAfter := lim.Allow(ip)
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			app.clientError(w, http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitExempted reports whether the address belongs to a network exempt
+// from rate limiting.
+func (app *application) limitExempted(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range app.limitExempt {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// timeout returns middleware that cancels the request context and sends
+// a 503 if the handler runs longer than d, so one slow query can't hold
+// a connection until WriteTimeout. Streaming routes must not use it:
+// http.TimeoutHandler's writer supports neither Flush nor Hijack. A zero
+// duration disables it.
+func timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+		return http.TimeoutHandler(next, d, http.StatusText(http.StatusServiceUnavailable))
+	}
+}
+
+// maxBytes returns middleware capping the request body at n bytes using
+// http.MaxBytesReader, so oversized submissions fail fast with a 413
+// instead of tying up the handler.
+func maxBytes(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// noSurf adds CSRF protection to state-changing requests, pairing a
+// customised token cookie with a hidden field in every form.
+func (app *application) noSurf(next http.Handler) http.Handler {
+	csrfHandler := nosurf.New(next)
+
+	// A body that blew through its maxBytes cap also fails the token
+	// check, because the form never parsed; report that as 413 rather
+	// than a generic CSRF failure. Draining the capped body is how we
+	// find out, since MaxBytesReader keeps returning its error.
+	csrfHandler.SetFailureHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.Copy(io.Discard, r.Body); err != nil {
+			app.clientError(w, formErrorStatus(err))
+			return
+		}
+		app.clientError(w, http.StatusBadRequest)
+	}))
+
+	csrfHandler.SetBaseCookie(http.Cookie{
+		HttpOnly: true,
+		Path:     "/",
+		Secure:   app.cfg.Server.TLS,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return csrfHandler
+}
+
+// secureHeaders sets a number of security-related headers on every
+// response. The Content-Security-Policy comes from configuration; a
+// fresh nonce is minted per request and substituted for any $NONCE
+// placeholder in the policy, then exposed to templates so inline assets
+// can be allowed without weakening the policy.
+func (app *application) secureHeaders(next http.Handler) http.Handler {
+	srv := app.cfg.Server
+
+	policy := srv.CSP
+	useNonce := strings.Contains(policy, "$NONCE")
+
+	// HSTS only makes sense when the app itself terminates TLS; the
+	// header value never varies so it is assembled once.
+	var hsts string
+	if srv.HSTSMaxAge > 0 && srv.TLS {
+		hsts = fmt.Sprintf("max-age=%d", int(srv.HSTSMaxAge.Seconds()))
+		if srv.HSTSSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if srv.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+
+	frameOptions := strings.ToUpper(srv.FrameOptions)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonce := newCSPNonce()
+
+		if useNonce {
+			w.Header().Set("Content-Security-Policy", strings.ReplaceAll(policy, "$NONCE", nonce))
+		} else if policy != "" {
+			w.Header().Set("Content-Security-Policy", policy)
+		}
+		if hsts != "" {
+			w.Header().Set("Strict-Transport-Security", hsts)
+		}
+		if srv.ReferrerPolicy != "" {
+			w.Header().Set("Referrer-Policy", srv.ReferrerPolicy)
+		}
+		if srv.PermissionsPolicy != "" {
+			w.Header().Set("Permissions-Policy", srv.PermissionsPolicy)
+		}
+		if frameOptions != "" {
+			w.Header().Set("X-Frame-Options", frameOptions)
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("X-XSS-Protection", "0")
+
+		ctx := context.WithValue(r.Context(), cspNonceContextKey, nonce)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// newCSPNonce returns a fresh random nonce for use in CSP source lists.
+func newCSPNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// logRequest emits a structured log line for every request — method, path,
+// status, response size, duration, referer, user agent and (when
+// available) the authenticated user's ID — and optionally appends an
+// Apache combined format line to the separate access log for existing log
+// tooling.
+func (app *application) logRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		lc := &logContext{}
+		r = r.WithContext(context.WithValue(r.Context(), logContextKey, lc))
+
+		sw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(sw, r)
+
+		attrs := []any{
+			"request_id", app.requestIDFrom(r),
+			"remote", app.clientIP(r),
+			"proto", r.Proto,
+			"method", r.Method,
+			"path", r.URL.RequestURI(),
+			"status", sw.status,
+			"bytes", sw.bytes,
+			"duration", time.Since(start),
+			"referer", r.Referer(),
+			"user_agent", r.UserAgent(),
+		}
+		if lc.userID != 0 {
+			attrs = append(attrs, "user_id", lc.userID)
+		}
+
+		app.logger.Info("request", attrs...)
+
+		if app.accessLog != nil {
+			host := app.clientIP(r)
+
+			user := "-"
+			if lc.userID != 0 {
+				user = strconv.Itoa(lc.userID)
+			}
+
+			app.accessLog.Printf("%s - %s [%s] %q %d %d %q %q",
+				host,
+				user,
+				start.Format("02/Jan/2006:15:04:05 -0700"),
+				fmt.Sprintf("%s %s %s", r.Method, r.URL.RequestURI(), r.Proto),
+				sw.status,
+				sw.bytes,
+				r.Referer(),
+				r.UserAgent(),
+			)
+		}
+	})
+}
+
+// recoverPanic gracefully handles any panic in the handler chain by closing
+// the connection and sending a 500 Internal Server Error response.
+func (app *application) recoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				w.Header().Set("Connection", "close")
+				app.serverError(w, r, fmt.Errorf("%s", err))
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// annotateLog records session-derived attributes (currently the
+// authenticated user's ID) into the request's logContext so logRequest can
+// include them. It must sit after the session middleware in the dynamic
+// chain.
+func (app *application) annotateLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if lc, ok := r.Context().Value(logContextKey).(*logContext); ok {
+			lc.userID = app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAdmin responds with 404 Not Found unless the request is from an
+// authenticated administrator, hiding the existence of admin-only routes
+// from everyone else.
+func (app *application) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.isAdmin(r) {
+			app.notFound(w, r)
+			return
+		}
+
+		w.Header().Add("Cache-Control", "no-store")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireDebugAccess restricts the debug-only endpoints (pprof, email
+// previews) to requests from the local machine or from authenticated
+// administrators, responding with 404 to everyone else so their
+// existence isn't advertised.
+func (app *application) requireDebugAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.isLoopback(r) && !app.isAdmin(r) {
+			app.notFound(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAuthentication redirects unauthenticated users to the login page
+// and prevents pages that require authentication from being cached.
+func (app *application) requireAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.isAuthenticated(r) {
+			http.Redirect(w, r, app.path("/user/login"), http.StatusSeeOther)
+			return
+		}
+
+		w.Header().Add("Cache-Control", "no-store")
+
+		next.ServeHTTP(w, r)
+	})
+}
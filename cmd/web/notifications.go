@@ -0,0 +1,187 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"snippetbox.ayubasayyed.net/internal/models"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// notificationLimit caps how many notifications the notifications page
+// shows; older entries simply age out of view.
+const notificationLimit = 50
+
+// notificationsView shows the signed-in user's recent notifications.
+func (app *application) notificationsView(w http.ResponseWriter, r *http.Request) {
+	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+
+	notifications, err := app.notifications.ForUser(userID, notificationLimit)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Notifications = notifications
+	data.Breadcrumbs = app.breadcrumbs(r, breadcrumb{Label: app.t(r, "notifications.title")})
+
+	app.render(w, r, http.StatusOK, "notifications.tmpl", data)
+}
+
+// notificationReadPost marks a single notification as read and returns
+// to the notifications page.
+func (app *application) notificationReadPost(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+
+	if err := app.notifications.MarkRead(id, userID); err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, app.path("/notifications"), http.StatusSeeOther)
+}
+
+// notificationsReadAllPost marks all of the user's notifications as read.
+func (app *application) notificationsReadAllPost(w http.ResponseWriter, r *http.Request) {
+	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+
+	if err := app.notifications.MarkAllRead(userID); err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, app.path("/notifications"), http.StatusSeeOther)
+}
+
+// notificationPrefRow pairs an event with its delivery toggles for the
+// preferences template.
+type notificationPrefRow struct {
+	Event string
+	InApp bool
+	Email bool
+}
+
+// notificationPrefs shows the per-event delivery toggles, with both
+// channels enabled for any event the user has never changed. The weekly
+// digest is the exception: it is opt-in, so it defaults to off.
+func (app *application) notificationPrefs(w http.ResponseWriter, r *http.Request) {
+	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+
+	prefs, err := app.notifications.Prefs(userID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	rows := make([]notificationPrefRow, 0, len(models.NotificationEvents))
+	for _, event := range models.NotificationEvents {
+		pref, ok := prefs[event]
+		if !ok && event != models.NotificationDigest {
+			pref = models.NotificationPref{InApp: true, Email: true}
+		}
+		rows = append(rows, notificationPrefRow{Event: event, InApp: pref.InApp, Email: pref.Email})
+	}
+
+	data := app.newTemplateData(r)
+	data.NotificationPrefs = rows
+	data.Breadcrumbs = app.breadcrumbs(r,
+		breadcrumb{Label: app.t(r, "notifications.title"), URL: app.path("/notifications")},
+		breadcrumb{Label: app.t(r, "notifications.prefs_title")})
+
+	app.render(w, r, http.StatusOK, "notification-prefs.tmpl", data)
+}
+
+// notificationPrefsPost stores the submitted toggles. Checkboxes only
+// appear in the form data when ticked, so absence means off.
+func (app *application) notificationPrefsPost(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+
+	for _, event := range models.NotificationEvents {
+		pref := models.NotificationPref{
+			InApp: r.PostForm.Get("in_app_"+event) == "on",
+			Email: r.PostForm.Get("email_"+event) == "on",
+		}
+
+		if err := app.notifications.SetPref(userID, event, pref); err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	app.flashSuccess(r, app.t(r, "flash.prefs_saved"))
+
+	http.Redirect(w, r, app.path("/notifications/prefs"), http.StatusSeeOther)
+}
+
+// notifyCommenters records a comment notification for everyone who has
+// commented on the snippet, except the comment's own author and anyone
+// in skip (typically users already notified of a mention). Failures are
+// logged rather than surfaced: the comment itself has already been saved.
+func (app *application) notifyCommenters(snippetID, actorID int, actorName string, skip map[int]bool) {
+	commenters, err := app.comments.Commenters(snippetID)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return
+	}
+
+	for _, userID := range commenters {
+		if userID == actorID || skip[userID] {
+			continue
+		}
+
+		app.notify(userID, models.NotificationComment, actorName, snippetID)
+	}
+}
+
+// notify records one notification, respecting the recipient's in-app
+// preference for the event. The email preference is consulted by the
+// mailer side of the subsystem when a kind has an email form. Failures
+// are logged rather than surfaced.
+func (app *application) notify(userID int, kind, actor string, snippetID int) {
+	prefs, err := app.notifications.Prefs(userID)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return
+	}
+
+	if pref, ok := prefs[kind]; ok && !pref.InApp {
+		return
+	}
+
+	if _, err := app.notifications.Insert(userID, kind, actor, snippetID); err != nil {
+		app.logger.Error(err.Error())
+	}
+}
+
+// unreadNotifications returns the unread count for the nav badge, or
+// zero for anonymous users.
+func (app *application) unreadNotifications(r *http.Request) int {
+	if !app.isAuthenticated(r) {
+		return 0
+	}
+
+	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+
+	count, err := app.notifications.UnreadCount(userID)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return 0
+	}
+
+	return count
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"snippetbox.ayubasayyed.net/internal/markdown"
+	"snippetbox.ayubasayyed.net/ui"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// staticPage is one Markdown content page (about, privacy and so on),
+// rendered to HTML once at startup.
+type staticPage struct {
+	Slug  string
+	Title string
+	HTML  template.HTML
+}
+
+// loadStaticPages renders every Markdown file embedded under ui/pages
+// into a page keyed by its slug — the filename without the extension.
+// A leading level-one heading becomes the page title and is dropped
+// from the body, since the base template already renders the title;
+// pages without one fall back to the slug.
+func loadStaticPages() (map[string]staticPage, error) {
+	pages := map[string]staticPage{}
+
+	err := fs.WalkDir(ui.Files, "pages", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ".md" {
+			return err
+		}
+
+		src, err := ui.Files.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		slug := strings.TrimSuffix(path.Base(p), ".md")
+
+		body := string(src)
+		title := slug
+
+		if line, rest, ok := strings.Cut(body, "\n"); ok && strings.HasPrefix(line, "# ") {
+			title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+			body = rest
+		}
+
+		html, err := markdown.Render(body)
+		if err != nil {
+			return err
+		}
+
+		pages[slug] = staticPage{Slug: slug, Title: title, HTML: html}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+// pageView renders one of the static content pages through the base
+// template.
+func (app *application) pageView(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	page, ok := app.pages[params.ByName("slug")]
+	if !ok {
+		app.notFound(w, r)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Page = &page
+	data.Breadcrumbs = app.breadcrumbs(r, breadcrumb{Label: page.Title})
+
+	app.render(w, r, http.StatusOK, "page.tmpl", data)
+}
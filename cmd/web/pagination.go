@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Pagination describes one page of a longer listing for the shared
+// pagination partial. Prev and Next are empty when there is no such
+// page.
+type Pagination struct {
+	Page       int
+	TotalPages int
+	PrevURL    string
+	NextURL    string
+}
+
+// pageParam returns the requested page number, defaulting to the first
+// page when the parameter is missing or nonsense.
+func pageParam(r *http.Request) int {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		return 1
+	}
+	return page
+}
+
+// newPagination builds the pagination for a listing of total items
+// shown perPage at a time, with page URLs that preserve the request's
+// other query parameters.
+func (app *application) newPagination(r *http.Request, page, perPage, total int) *Pagination {
+	totalPages := (total + perPage - 1) / perPage
+	if totalPages < 1 {
+		totalPages = 1
+	}
+
+	p := &Pagination{Page: page, TotalPages: totalPages}
+
+	if page > 1 {
+		p.PrevURL = app.pageURL(r, page-1)
+	}
+	if page < totalPages {
+		p.NextURL = app.pageURL(r, page+1)
+	}
+
+	return p
+}
+
+// pageURL rebuilds the request URL pointing at the given page, keeping
+// every other query parameter as it was.
+func (app *application) pageURL(r *http.Request, page int) string {
+	q := r.URL.Query()
+	if page <= 1 {
+		q.Del("page")
+	} else {
+		q.Set("page", strconv.Itoa(page))
+	}
+
+	u := app.path(r.URL.Path)
+	if enc := q.Encode(); enc != "" {
+		u += "?" + enc
+	}
+
+	return u
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"snippetbox.ayubasayyed.net/internal/markdown"
+	"snippetbox.ayubasayyed.net/internal/validator"
+)
+
+// previewMarkdown renders submitted Markdown to sanitized HTML, so the
+// create and edit forms can offer a live preview tab. Rendering is
+// comparatively expensive, so a dedicated per-IP limiter sits on top of
+// the global one; clients over it get a 429 with a Retry-After hint.
+func (app *application) previewMarkdown(w http.ResponseWriter, r *http.Request) {
+	if ok, retryAfter := app.previewLimiter.Allow(app.clientIP(r)); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		app.clientError(w, http.StatusTooManyRequests)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, formErrorStatus(err))
+		return
+	}
+
+	content := r.PostForm.Get("content")
+	if !validator.MaxChars(content, app.siteSettings().MaxSnippetSize) {
+		app.clientError(w, http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	html, err := markdown.Render(content)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(html))
+}
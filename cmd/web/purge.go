@@ -0,0 +1,68 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/models"
+)
+
+// purgeExpired hard-deletes snippets that expired before the cutoff and
+// session rows whose expiry has passed, returning how many rows of each
+// were removed. Expired rows are otherwise only filtered out of queries,
+// so this is how the tables are kept from growing forever.
+func purgeExpired(db *sql.DB, driver string, cutoff time.Time) (snippets, sessions int64, err error) {
+	m := &models.SnippetModel{DB: db}
+
+	snippets, err = m.Purge(cutoff)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The session stores encode expiry differently per dialect: a
+	// timestamp for MySQL, a julian day number for SQLite.
+	stmt := "DELETE FROM sessions WHERE expiry < UTC_TIMESTAMP()"
+	if driver == "sqlite3" {
+		stmt = "DELETE FROM sessions WHERE expiry < julianday('now')"
+	}
+
+	result, err := db.Exec(stmt)
+	if err != nil {
+		return snippets, 0, err
+	}
+
+	sessions, err = result.RowsAffected()
+	if err != nil {
+		return snippets, 0, err
+	}
+
+	return snippets, sessions, nil
+}
+
+// runPurge is the scheduled purge job: it removes snippets that expired
+// more than purge.retention ago, stale session rows and task rows that
+// finished before the cutoff, recording the rows removed in the
+// metrics.
+func (app *application) runPurge() error {
+	cutoff := time.Now().UTC().Add(-app.cfg.Purge.Retention)
+
+	snippets, sessions, err := purgeExpired(app.db, app.cfg.DB.Driver, cutoff)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := app.taskQueue.Purge(cutoff)
+	if err != nil {
+		return err
+	}
+
+	app.metrics.AddPurged("snippets", snippets)
+	app.metrics.AddPurged("sessions", sessions)
+	app.metrics.AddPurged("tasks", tasks)
+
+	if snippets > 0 || sessions > 0 || tasks > 0 {
+		app.logger.Info("purged expired rows", "snippets", snippets, "sessions", sessions, "tasks", tasks)
+	}
+
+	return nil
+}
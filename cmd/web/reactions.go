@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"snippetbox.ayubasayyed.net/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// reactionEmojis is the fixed set of reactions a snippet can receive.
+// Anything else submitted to the toggle endpoint is rejected.
+var reactionEmojis = []string{"👍", "❤️", "🎉", "🤔", "👀"}
+
+// snippetReactions builds the per-emoji rows for the view page: every
+// emoji in the fixed set, in order, with its count and whether the
+// viewing user has used it.
+func (app *application) snippetReactions(r *http.Request, snippetID int) ([]reactionRow, error) {
+	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+
+	counts, err := app.reactions.Counts(snippetID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byEmoji := map[string]reactionRow{}
+	for _, rc := range counts {
+		byEmoji[rc.Emoji] = reactionRow{Emoji: rc.Emoji, Count: rc.Count, Mine: rc.Mine}
+	}
+
+	rows := make([]reactionRow, 0, len(reactionEmojis))
+	for _, emoji := range reactionEmojis {
+		row, ok := byEmoji[emoji]
+		if !ok {
+			row = reactionRow{Emoji: emoji}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// reactionRow is one emoji's state on the view page.
+type reactionRow struct {
+	Emoji string
+	Count int
+	Mine  bool
+}
+
+// snippetReactPost toggles the signed-in user's reaction and returns to
+// the snippet.
+func (app *application) snippetReactPost(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id < 1 {
+		app.notFound(w, r)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	emoji := r.PostForm.Get("emoji")
+	if !validator.PermittedValue(emoji, reactionEmojis...) {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+
+	if _, err := app.reactions.Toggle(id, userID, emoji); err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, app.path(fmt.Sprintf("/snippet/view/%d", id)), http.StatusSeeOther)
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/pprof"
+	"regexp"
+	"strings"
+
+	"snippetbox.ayubasayyed.net/ui"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/justinas/alice"
+)
+
+// Per-route request body limits. Login and signup forms are tiny;
+// snippet and comment bodies get more headroom.
+const (
+	smallBodyLimit   = 4 << 10
+	snippetBodyLimit = 64 << 10
+)
+
+// routeGroup registers handlers on a shared router through a common
+// middleware chain, so related routes are declared together and new ones
+// pick up their group's middleware automatically.
+type routeGroup struct {
+	router *httprouter.Router
+	chain  alice.Chain
+}
+
+// group derives a new group whose chain appends the given middleware.
+func (g routeGroup) group(mw ...alice.Constructor) routeGroup {
+	return routeGroup{router: g.router, chain: g.chain.Append(mw...)}
+}
+
+// handle registers a handler function for the method and path through the
+// group's chain.
+func (g routeGroup) handle(method, path string, fn http.HandlerFunc) {
+	g.router.Handler(method, path, g.chain.ThenFunc(fn))
+}
+
+// handler registers a handler for the method and path through the group's
+// chain.
+func (g routeGroup) handler(method, path string, h http.Handler) {
+	g.router.Handler(method, path, g.chain.Then(h))
+}
+
+// routes returns the application's router, wrapped by the standard
+// middleware chain. Routes are registered in groups — api, public,
+// protected and admin — each with its own middleware chain.
+func (app *application) routes() http.Handler {
+	router := httprouter.New()
+
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app.notFound(w, r)
+	})
+
+	// Requests for a known path with the wrong method get a 405 rather
+	// than falling through to 404. The router fills in the Allow header
+	// before calling this, and answers OPTIONS requests itself.
+	router.HandleMethodNotAllowed = true
+	router.MethodNotAllowed = http.HandlerFunc(app.methodNotAllowed)
+	router.HandleOPTIONS = true
+	router.GlobalOPTIONS = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// Static files are served straight from the embedded filesystem; its
+	// paths begin with "static" so no prefix stripping is needed.
+	router.Handler(http.MethodGet, "/static/*filepath", newStaticHandler())
+
+	// The api group carries no extra middleware: operational probes and
+	// the streaming endpoints manage their own state.
+	api := routeGroup{router: router, chain: alice.New()}
+
+	api.handle(http.MethodGet, "/healthz", app.healthz)
+	api.handle(http.MethodGet, "/livez", app.livez)
+	api.handle(http.MethodGet, "/readyz", app.readyz)
+	api.handle(http.MethodGet, "/version", app.versionInfo)
+	api.handle(http.MethodGet, "/.well-known/security.txt", app.securityTxt)
+	api.handler(http.MethodGet, "/metrics", app.metrics.Handler())
+	api.handle(http.MethodGet, "/ws/firehose", app.wsFirehose)
+	api.handle(http.MethodGet, "/snippet/events/:id", app.snippetEvents)
+
+	// Browsers POST violation reports without a CSRF token, so this sits
+	// outside the noSurf chain.
+	api.group(maxBytes(smallBodyLimit)).handle(http.MethodPost, "/csp-report", app.cspReportPost)
+
+	// Copy beacons arrive via navigator.sendBeacon, which can't carry a
+	// CSRF token either.
+	api.group(maxBytes(smallBodyLimit)).handle(http.MethodPost, "/snippet/copied/:id", app.snippetCopiedPost)
+
+	if app.debug {
+		pprofMux := http.NewServeMux()
+		pprofMux.HandleFunc("/debug/pprof/", pprof.Index)
+		pprofMux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		pprofMux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		pprofMux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		pprofMux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+		debugGroup := api.group(app.sessionManager.LoadAndSave, app.requireDebugAccess)
+		debugGroup.handler(http.MethodGet, "/debug/pprof/*item", pprofMux)
+
+		// Email template previews, for iterating on layouts without
+		// sending real mail.
+		debugGroup.handle(http.MethodGet, "/dev/mail", app.devMailIndex)
+		debugGroup.handle(http.MethodGet, "/dev/mail/:template", app.devMailPreview)
+	}
+
+	public := api.group(app.sessionManager.LoadAndSave, app.noSurf, app.annotateLog)
+
+	// Form routes get their body cap applied before noSurf, which has to
+	// read the body to verify the CSRF token.
+	formGroup := func(limit int64, mw ...alice.Constructor) routeGroup {
+		g := api.group(app.sessionManager.LoadAndSave, maxBytes(limit), app.noSurf, app.annotateLog)
+		return g.group(mw...)
+	}
+
+	// Database-heavy pages run under the handler timeout.
+	slow := public.group(timeout(app.cfg.Server.HandlerTimeout))
+
+	slow.handle(http.MethodGet, "/", app.home)
+	slow.handle(http.MethodGet, "/snippet/view/:id", app.snippetView)
+	slow.handle(http.MethodGet, "/user/view/:id", app.userView)
+	slow.handle(http.MethodGet, "/search/suggest", app.searchSuggest)
+	slow.handle(http.MethodGet, "/status", app.status)
+	public.handle(http.MethodGet, "/pages/:slug", app.pageView)
+	public.handle(http.MethodGet, "/contact", app.contact)
+	public.handle(http.MethodGet, "/user/signup", app.userSignup)
+	publicForms := formGroup(smallBodyLimit)
+	publicForms.handle(http.MethodPost, "/user/signup", app.userSignupPost)
+	public.handle(http.MethodGet, "/user/login", app.userLogin)
+	publicForms.handle(http.MethodPost, "/user/login", app.userLoginPost)
+	publicForms.handle(http.MethodPost, "/user/locale", app.userLocalePost)
+	publicForms.handle(http.MethodPost, "/user/timezone", app.userTimezonePost)
+	publicForms.handle(http.MethodPost, "/user/theme", app.userThemePost)
+	publicForms.handle(http.MethodPost, "/contact", app.contactPost)
+	publicForms.handle(http.MethodPost, "/announcement/dismiss", app.announcementDismissPost)
+
+	protected := public.group(app.requireAuthentication)
+
+	protected.handle(http.MethodGet, "/snippet/create", app.snippetCreate)
+	protectedSlow := protected.group(timeout(app.cfg.Server.HandlerTimeout))
+	protectedSlow.handle(http.MethodGet, "/notifications", app.notificationsView)
+	protectedSlow.handle(http.MethodGet, "/notifications/prefs", app.notificationPrefs)
+	protectedForms := formGroup(snippetBodyLimit, app.requireAuthentication)
+	protectedForms.handle(http.MethodPost, "/snippet/create", app.snippetCreatePost)
+	protectedForms.handle(http.MethodPost, "/snippet/comment/:id", app.snippetCommentPost)
+	protectedForms.handle(http.MethodPost, "/preview/markdown", app.previewMarkdown)
+	protectedForms.handle(http.MethodPost, "/notifications/read/:id", app.notificationReadPost)
+	protectedForms.handle(http.MethodPost, "/notifications/read-all", app.notificationsReadAllPost)
+	formGroup(smallBodyLimit, app.requireAuthentication).handle(http.MethodPost, "/notifications/prefs", app.notificationPrefsPost)
+	formGroup(smallBodyLimit, app.requireAuthentication).handle(http.MethodPost, "/snippet/react/:id", app.snippetReactPost)
+	formGroup(smallBodyLimit, app.requireAuthentication).handle(http.MethodPost, "/user/logout", app.userLogoutPost)
+
+	admin := protected.group(app.requireAdmin)
+
+	formGroup(smallBodyLimit, app.requireAuthentication, app.requireAdmin).handle(http.MethodPost, "/admin/log-level", app.adminLogLevel)
+	adminSlow := admin.group(timeout(app.cfg.Server.HandlerTimeout))
+
+	adminSlow.handle(http.MethodGet, "/admin/db-stats", app.adminDBStats)
+	adminSlow.handle(http.MethodGet, "/admin/jobs", app.adminJobs)
+	adminSlow.handle(http.MethodGet, "/admin/tasks", app.adminTasks)
+	adminSlow.handle(http.MethodGet, "/admin/csp-reports", app.adminCSPReports)
+	adminSlow.handle(http.MethodGet, "/admin/contact", app.adminContactMessages)
+	admin.handle(http.MethodGet, "/admin/ip-filter", app.adminIPFilter)
+	formGroup(smallBodyLimit, app.requireAuthentication, app.requireAdmin).handle(http.MethodPost, "/admin/ip-filter", app.adminIPFilterPost)
+	admin.handle(http.MethodGet, "/admin/settings", app.adminSettings)
+	formGroup(smallBodyLimit, app.requireAuthentication, app.requireAdmin).handle(http.MethodPost, "/admin/settings", app.adminSettingsPost)
+
+	standard := alice.New(app.requestID, app.recoverPanic, app.trackMetrics, app.logRequest, app.filterIP, app.rateLimit, app.secureHeaders, compress)
+
+	// When a base path is configured the whole application is mounted
+	// under it, with the bare prefix redirecting to the prefixed root.
+	var handler http.Handler = router
+	if bp := app.cfg.Server.BasePath; bp != "" {
+		mux := http.NewServeMux()
+		mux.Handle(bp+"/", http.StripPrefix(bp, router))
+		mux.HandleFunc(bp, func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, bp+"/", http.StatusMovedPermanently)
+		})
+		handler = mux
+	}
+
+	return standard.Then(handler)
+}
+
+// fingerprintedAsset matches filenames carrying a content hash, which
+// can be cached forever because a content change means a new name.
+var fingerprintedAsset = regexp.MustCompile(`\.[0-9a-f]{8,}\.`)
+
+// newStaticHandler wraps the embedded static file server with caching
+// headers. ETags are hashed once at startup — the assets can't change
+// while the process runs — and conditional requests get a 304 instead of
+// the body.
+func newStaticHandler() http.Handler {
+	etags := map[string]string{}
+
+	fs.WalkDir(ui.Files, "static", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := ui.Files.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		etags["/"+path] = fmt.Sprintf("%q", fmt.Sprintf("%x", sum[:8]))
+		return nil
+	})
+
+	fileServer := http.FileServer(http.FS(ui.Files))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag, ok := etags[r.URL.Path]
+		if ok {
+			w.Header().Set("ETag", etag)
+			if fingerprintedAsset.MatchString(r.URL.Path) {
+				w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+			} else {
+				w.Header().Set("Cache-Control", "public, max-age=3600")
+			}
+
+			if match := r.Header.Get("If-None-Match"); match != "" && strings.Contains(match, etag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		fileServer.ServeHTTP(w, r)
+	})
+}
+
+// internalRoutes returns the handler served on any additional -addr
+// listeners: just the operational endpoints, so monitoring and debugging
+// can live on a port that is never exposed publicly. The pprof handlers
+// are mounted without the loopback check here because reachability of
+// the port itself is the access control.
+func (app *application) internalRoutes() http.Handler {
+	router := httprouter.New()
+
+	router.HandlerFunc(http.MethodGet, "/healthz", app.healthz)
+	router.HandlerFunc(http.MethodGet, "/livez", app.livez)
+	router.HandlerFunc(http.MethodGet, "/readyz", app.readyz)
+	router.HandlerFunc(http.MethodGet, "/version", app.versionInfo)
+	router.Handler(http.MethodGet, "/metrics", app.metrics.Handler())
+
+	pprofMux := http.NewServeMux()
+	pprofMux.HandleFunc("/debug/pprof/", pprof.Index)
+	pprofMux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	pprofMux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	pprofMux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	pprofMux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	router.Handler(http.MethodGet, "/debug/pprof/*item", pprofMux)
+
+	return alice.New(app.recoverPanic, app.logRequest).Then(router)
+}
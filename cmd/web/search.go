@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// suggestLimit caps how many suggestions one query returns.
+	suggestLimit = 10
+
+	// suggestMaxChars is the longest prefix worth querying for; anything
+	// longer is a paste, not a typeahead.
+	suggestMaxChars = 100
+
+	// suggestCacheTTL is how long a prefix's suggestions are served from
+	// memory before hitting the database again.
+	suggestCacheTTL = 30 * time.Second
+)
+
+// suggestCache holds recent suggestion results keyed by lowercased
+// prefix, so a burst of typeahead traffic for popular prefixes doesn't
+// translate into a burst of database queries.
+type suggestCache struct {
+	mu      sync.Mutex
+	entries map[string]suggestEntry
+}
+
+type suggestEntry struct {
+	titles []string
+	stored time.Time
+}
+
+func (c *suggestCache) get(key string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || time.Since(e.stored) > suggestCacheTTL {
+		return nil, false
+	}
+	return e.titles, true
+}
+
+func (c *suggestCache) set(key string, titles []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = map[string]suggestEntry{}
+	}
+
+	// Drop anything stale while we're here, so the map stays bounded by
+	// the prefixes queried within one TTL.
+	for k, e := range c.entries {
+		if time.Since(e.stored) > suggestCacheTTL {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = suggestEntry{titles: titles, stored: time.Now()}
+}
+
+// searchSuggest returns JSON title suggestions for the search box
+// typeahead, prefix-matched against unexpired snippets and cached
+// briefly per prefix. An empty or oversized query gets an empty list
+// rather than an error — the caller is JavaScript firing on every
+// keystroke.
+func (app *application) searchSuggest(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	titles := []string{}
+
+	if q != "" && utf8.RuneCountInString(q) <= suggestMaxChars {
+		key := strings.ToLower(q)
+
+		cached, ok := app.suggestions.get(key)
+		if ok {
+			titles = cached
+		} else {
+			var err error
+			titles, err = app.snippets.TitleSuggestions(q, suggestLimit)
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+			app.suggestions.set(key, titles)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "private, max-age=30")
+
+	json.NewEncoder(w).Encode(struct {
+		Suggestions []string `json:"suggestions"`
+	}{titles})
+}
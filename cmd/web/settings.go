@@ -0,0 +1,203 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"snippetbox.ayubasayyed.net/internal/models"
+	"snippetbox.ayubasayyed.net/internal/validator"
+)
+
+// siteSettings holds the admin-tunable site settings. Handlers read them
+// on every request, so the values are cached here and only touch the
+// database when an admin saves the form.
+type siteSettings struct {
+	SiteName       string
+	DefaultExpires int
+	SignupOpen     bool
+	MaxSnippetSize int
+
+	// Announcement is the site-wide banner text; empty means no banner.
+	// AnnouncementLevel picks its styling and AnnouncementExpires is an
+	// optional last day (2006-01-02) the banner shows.
+	Announcement        string
+	AnnouncementLevel   string
+	AnnouncementExpires string
+}
+
+// defaultSiteSettings are the values used for anything not stored in the
+// database yet.
+func defaultSiteSettings() siteSettings {
+	return siteSettings{
+		SiteName:          "Snippetbox",
+		DefaultExpires:    365,
+		SignupOpen:        true,
+		MaxSnippetSize:    10000,
+		AnnouncementLevel: flashLevelWarning,
+	}
+}
+
+// settingsService caches the site settings, writing through to the
+// settings table when they change.
+type settingsService struct {
+	model *models.SettingModel
+
+	mu      sync.RWMutex
+	current siteSettings
+}
+
+// newSettingsService loads the stored settings over the defaults.
+func newSettingsService(model *models.SettingModel) (*settingsService, error) {
+	stored, err := model.All()
+	if err != nil {
+		return nil, err
+	}
+
+	s := defaultSiteSettings()
+
+	if v, ok := stored["site_name"]; ok {
+		s.SiteName = v
+	}
+	if v, ok := stored["default_expires"]; ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			s.DefaultExpires = n
+		}
+	}
+	if v, ok := stored["signup_open"]; ok {
+		s.SignupOpen = v == "true"
+	}
+	if v, ok := stored["max_snippet_size"]; ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			s.MaxSnippetSize = n
+		}
+	}
+	if v, ok := stored["announcement"]; ok {
+		s.Announcement = v
+	}
+	if v, ok := stored["announcement_level"]; ok {
+		s.AnnouncementLevel = v
+	}
+	if v, ok := stored["announcement_expires"]; ok {
+		s.AnnouncementExpires = v
+	}
+
+	return &settingsService{model: model, current: s}, nil
+}
+
+// get returns a copy of the current settings.
+func (svc *settingsService) get() siteSettings {
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
+	return svc.current
+}
+
+// update persists the new settings and swaps the cache. The cache is
+// only updated once every row has been written, so a database error
+// leaves the previous values in service.
+func (svc *settingsService) update(s siteSettings) error {
+	rows := map[string]string{
+		"site_name":            s.SiteName,
+		"default_expires":      strconv.Itoa(s.DefaultExpires),
+		"signup_open":          strconv.FormatBool(s.SignupOpen),
+		"max_snippet_size":     strconv.Itoa(s.MaxSnippetSize),
+		"announcement":         s.Announcement,
+		"announcement_level":   s.AnnouncementLevel,
+		"announcement_expires": s.AnnouncementExpires,
+	}
+
+	for name, value := range rows {
+		if err := svc.model.Set(name, value); err != nil {
+			return err
+		}
+	}
+
+	svc.mu.Lock()
+	svc.current = s
+	svc.mu.Unlock()
+
+	return nil
+}
+
+// siteSettings returns the current settings, falling back to the
+// defaults when no settings service is wired up (check-templates runs
+// without a database).
+func (app *application) siteSettings() siteSettings {
+	if app.settings == nil {
+		return defaultSiteSettings()
+	}
+	return app.settings.get()
+}
+
+// settingsForm represents the admin settings form and its validation
+// errors.
+type settingsForm struct {
+	SiteName            string `form:"site_name"`
+	DefaultExpires      int    `form:"default_expires"`
+	SignupOpen          bool   `form:"signup_open"`
+	MaxSnippetSize      int    `form:"max_snippet_size"`
+	Announcement        string `form:"announcement"`
+	AnnouncementLevel   string `form:"announcement_level"`
+	AnnouncementExpires string `form:"announcement_expires"`
+	validator.Validator `form:"-"`
+}
+
+// adminSettings shows the site settings form.
+func (app *application) adminSettings(w http.ResponseWriter, r *http.Request) {
+	s := app.settings.get()
+
+	data := app.newTemplateData(r)
+	data.Form = settingsForm{
+		SiteName:            s.SiteName,
+		DefaultExpires:      s.DefaultExpires,
+		SignupOpen:          s.SignupOpen,
+		MaxSnippetSize:      s.MaxSnippetSize,
+		Announcement:        s.Announcement,
+		AnnouncementLevel:   s.AnnouncementLevel,
+		AnnouncementExpires: s.AnnouncementExpires,
+	}
+	data.Breadcrumbs = app.breadcrumbs(r, breadcrumb{Label: "Site Settings"})
+
+	app.render(w, r, http.StatusOK, "settings.tmpl", data)
+}
+
+// adminSettingsPost validates and saves the site settings.
+func (app *application) adminSettingsPost(w http.ResponseWriter, r *http.Request) {
+	var form settingsForm
+
+	ok := handleForm(app, w, r, &form, "settings.tmpl", func() {
+		form.CheckField(validator.NotBlank(form.SiteName), "site_name", "This field cannot be blank")
+		form.CheckField(validator.MaxChars(form.SiteName, 100), "site_name", "This field cannot be more than 100 characters long")
+		form.CheckField(validator.PermittedValue(form.DefaultExpires, 1, 7, 365), "default_expires", "This field must equal 1, 7 or 365")
+		form.CheckField(form.MaxSnippetSize > 0, "max_snippet_size", "This field must be a positive number")
+		form.CheckField(validator.MaxChars(form.Announcement, 500), "announcement", "This field cannot be more than 500 characters long")
+		form.CheckField(validator.PermittedValue(form.AnnouncementLevel, announcementLevels...), "announcement_level", "This field must be success, warning or error")
+		if form.AnnouncementExpires != "" {
+			_, ok := validator.Date(form.AnnouncementExpires, announcementDateLayout)
+			form.CheckField(ok, "announcement_expires", "This field must be a date in the form 2006-01-02")
+		}
+	}, nil)
+	if !ok {
+		return
+	}
+
+	err := app.settings.update(siteSettings{
+		SiteName:            form.SiteName,
+		DefaultExpires:      form.DefaultExpires,
+		SignupOpen:          form.SignupOpen,
+		MaxSnippetSize:      form.MaxSnippetSize,
+		Announcement:        form.Announcement,
+		AnnouncementLevel:   form.AnnouncementLevel,
+		AnnouncementExpires: form.AnnouncementExpires,
+	})
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.logger.Info("site settings changed")
+
+	app.flashSuccess(r, "Settings saved.")
+
+	http.Redirect(w, r, app.path("/admin/settings"), http.StatusSeeOther)
+}
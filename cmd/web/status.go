@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/version"
+)
+
+// statusCounters aggregates request and error counts into per-minute
+// buckets covering the last hour, so the status page can show recent rates
+// without a monitoring stack.
+type statusCounters struct {
+	mu      sync.Mutex
+	started time.Time
+	buckets [60]statusBucket
+}
+
+type statusBucket struct {
+	minute   int64
+	requests int
+	errors   int
+}
+
+func newStatusCounters() *statusCounters {
+	return &statusCounters{started: time.Now()}
+}
+
+// record counts one completed request, treating any 5xx response as an
+// error.
+func (c *statusCounters) record(status int) {
+	minute := time.Now().Unix() / 60
+	i := minute % 60
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.buckets[i].minute != minute {
+		c.buckets[i] = statusBucket{minute: minute}
+	}
+
+	c.buckets[i].requests++
+	if status >= 500 {
+		c.buckets[i].errors++
+	}
+}
+
+// lastHour returns the total requests and errors recorded over the past 60
+// minutes.
+func (c *statusCounters) lastHour() (requests, errors int) {
+	cutoff := time.Now().Unix()/60 - 60
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, b := range c.buckets {
+		if b.minute > cutoff {
+			requests += b.requests
+			errors += b.errors
+		}
+	}
+	return requests, errors
+}
+
+// uptime returns how long the application has been running.
+func (c *statusCounters) uptime() time.Duration {
+	return time.Since(c.started).Round(time.Second)
+}
+
+// statusData is the view data for the public status page.
+type statusData struct {
+	Uptime    time.Duration
+	Version   string
+	Commit    string
+	Requests  int
+	Errors    int
+	DBHealthy bool
+}
+
+// status renders a lightweight public status page with uptime, build info,
+// request/error totals for the last hour and database health.
+func (app *application) status(w http.ResponseWriter, r *http.Request) {
+	requests, errors := app.stats.lastHour()
+
+	build := version.Get()
+
+	data := app.newTemplateData(r)
+	data.Status = &statusData{
+		Uptime:    app.stats.uptime(),
+		Version:   build.Version,
+		Commit:    build.Commit,
+		Requests:  requests,
+		Errors:    errors,
+		DBHealthy: app.db.PingContext(r.Context()) == nil,
+	}
+
+	app.render(w, r, http.StatusOK, "status.tmpl", data)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"log/syslog"
+	"strings"
+	"sync"
+)
+
+// syslogHandler is a slog.Handler that delivers records to the local syslog
+// daemon (which journald also consumes), mapping slog levels onto syslog
+// priorities. Records are formatted in logfmt via an inner text handler,
+// with the timestamp dropped since syslog adds its own.
+type syslogHandler struct {
+	mu     *sync.Mutex
+	buf    *bytes.Buffer
+	inner  slog.Handler
+	writer *syslog.Writer
+}
+
+// newSyslogHandler returns a syslogHandler writing to w. The level
+// reported by Enabled is controlled by level, which may be a *slog.LevelVar
+// for runtime adjustment.
+func newSyslogHandler(w *syslog.Writer, level slog.Leveler) *syslogHandler {
+	buf := new(bytes.Buffer)
+
+	inner := slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+
+	return &syslogHandler{
+		mu:     &sync.Mutex{},
+		buf:    buf,
+		inner:  inner,
+		writer: w,
+	}
+}
+
+func (h *syslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *syslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buf.Reset()
+
+	if err := h.inner.Handle(ctx, r); err != nil {
+		return err
+	}
+
+	msg := strings.TrimSpace(h.buf.String())
+
+	switch {
+	case r.Level >= slog.LevelError:
+		return h.writer.Err(msg)
+	case r.Level >= slog.LevelWarn:
+		return h.writer.Warning(msg)
+	case r.Level >= slog.LevelInfo:
+		return h.writer.Info(msg)
+	default:
+		return h.writer.Debug(msg)
+	}
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &syslogHandler{mu: h.mu, buf: h.buf, inner: h.inner.WithAttrs(attrs), writer: h.writer}
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	return &syslogHandler{mu: h.mu, buf: h.buf, inner: h.inner.WithGroup(name), writer: h.writer}
+}
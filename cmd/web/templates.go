@@ -0,0 +1,193 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/i18n"
+	"snippetbox.ayubasayyed.net/internal/jobs"
+	"snippetbox.ayubasayyed.net/internal/models"
+	"snippetbox.ayubasayyed.net/internal/tasks"
+	"snippetbox.ayubasayyed.net/ui"
+)
+
+// templateData is the holding structure for any dynamic data passed to the
+// HTML templates.
+type templateData struct {
+	CurrentYear         int
+	SiteName            string
+	CanonicalURL        string
+	Locale              string
+	Locales             []string
+	Clock               clock
+	Timezone            string
+	Timezones           []string
+	Theme               string
+	Themes              []string
+	Announcement        *announcement
+	Breadcrumbs         []breadcrumb
+	Snippet             *models.Snippet
+	Snippets            []*models.Snippet
+	Page                *staticPage
+	Profile             *models.User
+	Pagination          *Pagination
+	Comments            []*models.Comment
+	MentionUsers        map[string]int
+	Reactions           []reactionRow
+	Form                any
+	Status              *statusData
+	DBStats             *sql.DBStats
+	Jobs                []jobs.Status
+	Tasks               []tasks.TaskInfo
+	CSPReports          []cspReport
+	ContactMessages     []*models.ContactMessage
+	Notifications       []*models.Notification
+	NotificationPrefs   []notificationPrefRow
+	Flashes             []flashMessage
+	IsAuthenticated     bool
+	UnreadNotifications int
+	CSRFToken           string
+	CSPNonce            string
+	ErrorReference      string
+}
+
+// assetIntegrity computes Subresource Integrity hashes for the embedded
+// static assets, keyed by URL path. The assets can't change while the
+// process runs, so this happens once.
+func assetIntegrity() map[string]string {
+	hashes := map[string]string{}
+
+	fs.WalkDir(ui.Files, "static", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := ui.Files.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		hashes["/"+path] = "sha256-" + base64.StdEncoding.EncodeToString(sum[:])
+		return nil
+	})
+
+	return hashes
+}
+
+// pluralize picks the singular or plural form for a count.
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
+// truncate shortens s to at most n runes, appending an ellipsis when
+// anything was cut.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
+
+// since returns a rough human description of how long ago t was.
+func since(t time.Time) string {
+	d := time.Since(t)
+
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", d/time.Minute)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dd ago", d/(24*time.Hour))
+	}
+}
+
+// templateFuncs is the single registry of functions available to the
+// templates. Helpers that need config- or startup-scoped state (the
+// base path, asset host, integrity hashes, message catalogs) are bound
+// from the arguments here; adding a helper means adding one entry.
+func templateFuncs(basePath, assetBase string, tr *i18n.Translator) template.FuncMap {
+	integrity := assetIntegrity()
+
+	return template.FuncMap{
+		// Application URLs go through urlFor so they respect the
+		// configured base path; asset URLs go through assetFor, which
+		// points them at the asset host when one is configured so a CDN
+		// can front them without template edits.
+		"urlFor": func(p string) string {
+			return basePath + p
+		},
+		"assetFor": func(p string) string {
+			if assetBase != "" {
+				return assetBase + p
+			}
+			return basePath + p
+		},
+		"integrity": func(p string) string {
+			return integrity[p]
+		},
+
+		// User-facing text goes through T, which looks the message up in
+		// the catalog for the locale carried in the template data.
+		"T": tr.T,
+
+		// humanDate renders a time with the viewer's clock from the
+		// template data.
+		"humanDate": func(c clock, t time.Time) string {
+			return c.Human(t)
+		},
+
+		// mentions renders a comment body with resolved @name tokens
+		// linked to the users' profiles.
+		"mentions": mentionize(basePath),
+
+		"pluralize": pluralize,
+		"truncate":  truncate,
+		"since":     since,
+	}
+}
+
+// newTemplateCache parses all the page templates, along with the base layout
+// and partials, from the embedded filesystem into an in-memory cache keyed
+// by page name. The templates get their helper functions from the
+// templateFuncs registry.
+func newTemplateCache(basePath, assetBase string, tr *i18n.Translator) (map[string]*template.Template, error) {
+	functions := templateFuncs(basePath, assetBase, tr)
+
+	cache := map[string]*template.Template{}
+
+	pages, err := fs.Glob(ui.Files, "html/pages/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, page := range pages {
+		name := filepath.Base(page)
+
+		patterns := []string{
+			"html/base.tmpl",
+			"html/partials/*.tmpl",
+			page,
+		}
+
+		ts, err := template.New(name).Funcs(functions).ParseFS(ui.Files, patterns...)
+		if err != nil {
+			return nil, err
+		}
+
+		cache[name] = ts
+	}
+
+	return cache, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "comments"},
+		{1, "comment"},
+		{2, "comments"},
+	}
+
+	for _, tt := range tests {
+		if got := pluralize(tt.n, "comment", "comments"); got != tt.want {
+			t.Errorf("pluralize(%d) = %q; want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"this is too long", 7, "this is…"},
+		{"héllo wörld", 5, "héllo…"},
+		{"", 5, ""},
+	}
+
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q; want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSince(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{now.Add(-10 * time.Second), "just now"},
+		{now.Add(-5 * time.Minute), "5m ago"},
+		{now.Add(-3 * time.Hour), "3h ago"},
+		{now.Add(-49 * time.Hour), "2d ago"},
+	}
+
+	for _, tt := range tests {
+		if got := since(tt.t); got != tt.want {
+			t.Errorf("since(%v) = %q; want %q", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestClockHuman(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := clock{loc: loc, layout: "02 Jan 2006 at 15:04"}
+
+	when := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if got, want := c.Human(when), "01 Mar 2024 at 07:00"; got != want {
+		t.Errorf("Human() = %q; want %q", got, want)
+	}
+}
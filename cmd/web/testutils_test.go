@@ -0,0 +1,227 @@
+package main
+
+import (
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/form/v4"
+
+	"snippetbox.ayubasayyed.net/internal/feed"
+	"snippetbox.ayubasayyed.net/internal/i18n"
+	"snippetbox.ayubasayyed.net/internal/jobs"
+	"snippetbox.ayubasayyed.net/internal/metrics"
+	"snippetbox.ayubasayyed.net/internal/models/mocks"
+	"snippetbox.ayubasayyed.net/internal/ratelimit"
+)
+
+// newTestApplication builds an application suitable for handler tests:
+// real templates, translator and session manager, discarded logs, and
+// mock stores instead of a database. Tests that need different canned
+// data or a failing store swap in their own mock after the call.
+func newTestApplication(t *testing.T) *application {
+	t.Helper()
+
+	cfg := defaultConfig()
+	cfg.Session.Store = "memory"
+
+	translator, err := i18n.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templateCache, err := newTemplateCache(cfg.Server.BasePath, cfg.Server.AssetURL, translator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pages, err := loadStaticPages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := &application{
+		cfg:            cfg,
+		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		logLevel:       new(slog.LevelVar),
+		snippets:       &mocks.SnippetStore{},
+		users:          &mocks.UserStore{},
+		comments:       &mocks.CommentStore{},
+		notifications:  &mocks.NotificationStore{},
+		reactions:      &mocks.ReactionStore{},
+		contactMsgs:    &mocks.ContactStore{},
+		loginDevices:   &mocks.LoginDeviceStore{},
+		templateCache:  templateCache,
+		translator:     translator,
+		formDecoder:    form.NewDecoder(),
+		sessionManager: newSCSManager(cfg, nil),
+		firehose:       feed.NewHub(),
+		commentFeed:    feed.NewCommentHub(),
+		scheduler:      jobs.NewScheduler(),
+		metrics:        metrics.New(),
+		stats:          newStatusCounters(),
+		ipFilter:       &ipFilter{},
+		cspLimiter:     ratelimit.New(1, 5),
+		cspReports:     &cspReportLog{},
+		previewLimiter: ratelimit.New(5, 10),
+		contactLimiter: ratelimit.New(0.1, 3),
+		suggestions:    &suggestCache{},
+		pages:          pages,
+		copyCounts:     &copyCounter{},
+	}
+
+	app.settings = &settingsService{current: defaultSiteSettings()}
+
+	go app.firehose.Run()
+
+	return app
+}
+
+// testServer wraps httptest's TLS server with a cookie jar, so session
+// and CSRF cookies survive across requests in a test, and with redirects
+// disabled, so tests can assert on the 3xx responses themselves.
+type testServer struct {
+	*httptest.Server
+}
+
+// newTestServer starts a TLS test server for the given handler and shuts
+// it down when the test finishes.
+func newTestServer(t *testing.T, h http.Handler) *testServer {
+	t.Helper()
+
+	ts := httptest.NewTLSServer(h)
+	t.Cleanup(ts.Close)
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.Client().Jar = jar
+
+	ts.Client().CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	return &testServer{ts}
+}
+
+// get makes a GET request for the given path and returns the status
+// code, headers and body.
+func (ts *testServer) get(t *testing.T, urlPath string) (int, http.Header, string) {
+	t.Helper()
+
+	rs, err := ts.Client().Get(ts.URL + urlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Body.Close()
+
+	body, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rs.StatusCode, rs.Header, string(body)
+}
+
+// postForm submits a form to the given path and returns the status code,
+// headers and body.
+func (ts *testServer) postForm(t *testing.T, urlPath string, form url.Values) (int, http.Header, string) {
+	t.Helper()
+
+	rs, err := ts.Client().PostForm(ts.URL+urlPath, form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Body.Close()
+
+	body, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rs.StatusCode, rs.Header, string(body)
+}
+
+// csrfToken fetches the page at urlPath and returns its CSRF token, for
+// posting forms whose page a test doesn't otherwise care about.
+func (ts *testServer) csrfToken(t *testing.T, urlPath string) string {
+	t.Helper()
+
+	_, _, body := ts.get(t, urlPath)
+	return extractCSRFToken(t, body)
+}
+
+// submitForm fetches the form page at urlPath to pick up a CSRF token,
+// folds it into form, and posts the result back to urlPath — the
+// render/extract/post dance every form submission needs.
+func (ts *testServer) submitForm(t *testing.T, urlPath string, form url.Values) (int, http.Header, string) {
+	t.Helper()
+
+	vals := url.Values{}
+	for key, values := range form {
+		vals[key] = values
+	}
+	vals.Set("csrf_token", ts.csrfToken(t, urlPath))
+
+	return ts.postForm(t, urlPath, vals)
+}
+
+// login submits the login form for the given credentials and fails the
+// test if they are rejected. The session cookie lands in the cookie jar,
+// so subsequent requests through this testServer are authenticated.
+func (ts *testServer) login(t *testing.T, email, password string) {
+	t.Helper()
+
+	code, _, _ := ts.submitForm(t, "/user/login", url.Values{
+		"email":    {email},
+		"password": {password},
+	})
+	if code != http.StatusSeeOther {
+		t.Fatalf("login as %s: got status %d; want %d", email, code, http.StatusSeeOther)
+	}
+}
+
+// loginAsMockUser logs in as the canned mocks.User, for tests of
+// protected routes against the mock stores.
+func (ts *testServer) loginAsMockUser(t *testing.T) {
+	t.Helper()
+
+	ts.login(t, mocks.User.Email, "pa$$word")
+}
+
+var csrfTokenRX = regexp.MustCompile(`<input type='hidden' name='csrf_token' value='(.+?)'>`)
+
+// extractCSRFToken pulls the CSRF token out of a rendered form, undoing
+// the HTML escaping the template engine applies to the value.
+func extractCSRFToken(t *testing.T, body string) string {
+	t.Helper()
+
+	matches := csrfTokenRX.FindStringSubmatch(body)
+	if len(matches) < 2 {
+		t.Fatal("no csrf token found in body")
+	}
+
+	return html.UnescapeString(matches[1])
+}
+
+func TestLivez(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+
+	code, _, body := ts.get(t, "/livez")
+
+	if code != http.StatusOK {
+		t.Errorf("got status %d; want %d", code, http.StatusOK)
+	}
+	if want := `{"status":"ok"}`; !strings.Contains(body, want) {
+		t.Errorf("body = %q; want it to contain %q", body, want)
+	}
+}
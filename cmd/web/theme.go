@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+)
+
+// sessionKeyTheme stores the visitor's explicit theme choice.
+const sessionKeyTheme = "theme"
+
+// themeDefault lets the visitor's operating system decide via the
+// prefers-color-scheme media query.
+const themeDefault = "system"
+
+// themes are the accepted theme names. Each becomes a theme-<name>
+// class on <body>.
+var themes = []string{themeDefault, "light", "dark"}
+
+// validTheme reports whether name is one of the accepted themes.
+func validTheme(name string) bool {
+	for _, t := range themes {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// theme returns the theme for the request: the preference stored in
+// the session if there is one, otherwise the system default.
+func (app *application) theme(r *http.Request) string {
+	if t := app.sessionManager.GetString(r.Context(), sessionKeyTheme); validTheme(t) {
+		return t
+	}
+
+	return themeDefault
+}
+
+// userThemePost stores the visitor's preferred theme in their session.
+func (app *application) userThemePost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, formErrorStatus(err))
+		return
+	}
+
+	theme := r.PostForm.Get("theme")
+	if !validTheme(theme) {
+		app.clientError(w, http.StatusUnprocessableEntity)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), sessionKeyTheme, theme)
+
+	http.Redirect(w, r, app.path("/"), http.StatusSeeOther)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sessionKeyTimezone stores the visitor's explicit timezone choice.
+const sessionKeyTimezone = "timezone"
+
+// commonTimezones is the list offered in the timezone picker. Any IANA
+// name is accepted on submit, so the list only needs to cover the
+// common cases.
+var commonTimezones = []string{
+	"UTC",
+	"Europe/London",
+	"Europe/Berlin",
+	"Europe/Paris",
+	"America/New_York",
+	"America/Chicago",
+	"America/Los_Angeles",
+	"Asia/Kolkata",
+	"Asia/Tokyo",
+	"Australia/Sydney",
+}
+
+// locationCache avoids re-reading the timezone database on every
+// request; locations never change while the process runs.
+var locationCache sync.Map
+
+// lookupLocation is time.LoadLocation with a cache in front.
+func lookupLocation(name string) (*time.Location, error) {
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+
+	locationCache.Store(name, loc)
+
+	return loc, nil
+}
+
+// clock renders times in the viewer's timezone using the date layout
+// for their locale. It travels through templateData so the humanDate
+// template function needs no request state of its own.
+type clock struct {
+	loc    *time.Location
+	layout string
+}
+
+// Human returns the nicely formatted local representation of t.
+func (c clock) Human(t time.Time) string {
+	return t.In(c.loc).Format(c.layout)
+}
+
+// timezone returns the timezone name for the request: the preference
+// stored in the session if it is valid, otherwise the configured
+// default.
+func (app *application) timezone(r *http.Request) string {
+	if tz := app.sessionManager.GetString(r.Context(), sessionKeyTimezone); tz != "" {
+		if _, err := lookupLocation(tz); err == nil {
+			return tz
+		}
+	}
+
+	return app.cfg.Server.Timezone
+}
+
+// clock builds the localized clock for the request.
+func (app *application) clock(r *http.Request) clock {
+	loc, err := lookupLocation(app.timezone(r))
+	if err != nil {
+		loc = time.UTC
+	}
+
+	return clock{loc: loc, layout: app.t(r, "format.datetime")}
+}
+
+// userTimezonePost stores the visitor's preferred timezone in their
+// session, overriding the configured default from then on.
+func (app *application) userTimezonePost(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, formErrorStatus(err))
+		return
+	}
+
+	tz := r.PostForm.Get("timezone")
+	if _, err := lookupLocation(tz); err != nil {
+		app.clientError(w, http.StatusUnprocessableEntity)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), sessionKeyTimezone, tz)
+
+	http.Redirect(w, r, app.path("/"), http.StatusSeeOther)
+}
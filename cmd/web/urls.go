@@ -0,0 +1,24 @@
+package main
+
+import "net/http"
+
+// absoluteURL returns the absolute URL for an application-relative
+// path, for canonical links, feeds and anywhere else a URL leaves the
+// site. When server.base_url is configured it is authoritative — the
+// deployment knows its public address better than any request header —
+// otherwise the URL is rebuilt from the request's scheme and host,
+// which already account for trusted proxies. The configured base path
+// is prepended either way.
+func (app *application) absoluteURL(r *http.Request, p string) string {
+	if base := app.cfg.Server.BaseURL; base != "" {
+		return base + app.path(p)
+	}
+
+	return app.requestScheme(r) + "://" + r.Host + app.path(p)
+}
+
+// canonicalURL returns the absolute URL search engines should index for
+// this request: the page's path without any query string.
+func (app *application) canonicalURL(r *http.Request) string {
+	return app.absoluteURL(r, r.URL.Path)
+}
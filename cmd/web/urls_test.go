@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAbsoluteURL(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "http://snippetbox.local/snippet/view/1?x=1", nil)
+
+	app := &application{}
+	if got, want := app.absoluteURL(r, "/snippet/view/1"), "http://snippetbox.local/snippet/view/1"; got != want {
+		t.Errorf("absoluteURL() = %q; want %q", got, want)
+	}
+
+	app.cfg.Server.BaseURL = "https://snippetbox.example.com"
+	app.cfg.Server.BasePath = "/box"
+	if got, want := app.absoluteURL(r, "/snippet/view/1"), "https://snippetbox.example.com/box/snippet/view/1"; got != want {
+		t.Errorf("absoluteURL() with base_url = %q; want %q", got, want)
+	}
+}
+
+func TestCanonicalURL(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "http://snippetbox.local/?page=2", nil)
+
+	app := &application{}
+	if got, want := app.canonicalURL(r), "http://snippetbox.local/"; got != want {
+		t.Errorf("canonicalURL() = %q; want the path without the query string, %q", got, want)
+	}
+}
@@ -0,0 +1,43 @@
+module snippetbox.ayubasayyed.net
+
+go 1.21
+
+toolchain go1.21.6
+
+require (
+	github.com/alexedwards/scs/mysqlstore v0.0.0-20230217120314-6b1bedc0f08c
+	github.com/alexedwards/scs/redisstore v0.0.0-20230217120314-6b1bedc0f08c
+	github.com/alexedwards/scs/sqlite3store v0.0.0-20230217120314-6b1bedc0f08c
+	github.com/alexedwards/scs/v2 v2.7.0
+	github.com/getsentry/sentry-go v0.27.0
+	github.com/go-playground/form/v4 v4.3.0
+	github.com/go-sql-driver/mysql v1.7.1
+	github.com/gomodule/redigo v1.8.9
+	github.com/gorilla/websocket v1.5.1
+	github.com/julienschmidt/httprouter v1.3.0
+	github.com/justinas/alice v1.2.0
+	github.com/justinas/nosurf v1.1.1
+	github.com/mattn/go-sqlite3 v1.14.22
+	github.com/microcosm-cc/bluemonday v1.0.26
+	github.com/prometheus/client_golang v1.19.1
+	github.com/yuin/goldmark v1.7.0
+	golang.org/x/crypto v0.18.0
+	golang.org/x/term v0.16.0
+	golang.org/x/text v0.14.0
+	golang.org/x/time v0.5.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/aymerick/douceur v0.2.0 // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/gorilla/css v1.0.0 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/net v0.20.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+)
@@ -0,0 +1,342 @@
+// Package cookiesession implements stateless sessions stored entirely in
+// an encrypted, authenticated cookie, so small deployments can scale
+// horizontally without a shared session store. Session data is encrypted
+// with NaCl secretbox; multiple keys are accepted for decryption so keys
+// can be rotated without logging everyone out.
+package cookiesession
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// cookieName is the default name of the session cookie.
+const cookieName = "session"
+
+// maxCookieSize is the largest encoded cookie value written. Browsers cap
+// cookies around 4KB; beyond that they silently truncate or drop them, so
+// it is better to refuse the write and report it.
+const maxCookieSize = 4000
+
+// ErrTooLarge is reported when the session data no longer fits in a
+// cookie.
+var ErrTooLarge = errors.New("cookiesession: session data too large for a cookie")
+
+// contextKey is a private type for the session's context key.
+type contextKey struct{}
+
+// session is the per-request session state.
+type session struct {
+	deadline time.Time
+	values   map[string]any
+	modified bool
+}
+
+// payload is the gob-encoded form of a session.
+type payload struct {
+	Deadline time.Time
+	Values   map[string]any
+}
+
+// Manager loads and saves cookie sessions. It implements the same method
+// subset as scs.SessionManager that the application uses.
+type Manager struct {
+	// OnError, if set, is called when a session cannot be saved (for
+	// example when it exceeds the cookie size limit).
+	OnError func(err error)
+
+	// Insecure drops the cookie's Secure attribute, for deployments
+	// serving plain HTTP behind a TLS-terminating proxy.
+	Insecure bool
+
+	// Name, Domain, Path and SameSite control the corresponding cookie
+	// attributes. New fills in the defaults.
+	Name     string
+	Domain   string
+	Path     string
+	SameSite http.SameSite
+
+	keys     [][32]byte
+	lifetime time.Duration
+}
+
+// New returns a Manager using the given 32-byte keys. The first key
+// encrypts new cookies; every key is tried when decrypting, so rotating
+// keys means prepending a new one and dropping the oldest later.
+func New(keys [][]byte, lifetime time.Duration) (*Manager, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("cookiesession: at least one key is required")
+	}
+
+	m := &Manager{
+		Name:     cookieName,
+		Path:     "/",
+		SameSite: http.SameSiteLaxMode,
+		lifetime: lifetime,
+	}
+
+	for _, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("cookiesession: keys must be 32 bytes, got %d", len(key))
+		}
+		var k [32]byte
+		copy(k[:], key)
+		m.keys = append(m.keys, k)
+	}
+
+	return m, nil
+}
+
+// LoadAndSave decodes the session cookie before the request is handled
+// and writes it back if it was modified. The cookie has to go out with
+// the response headers, so the handler runs against a wrapped writer
+// that saves the session just before the first byte of the response is
+// written — saving after the handler returns would silently lose the
+// cookie whenever the handler itself commits the headers, as a redirect
+// or a body larger than the server's buffers does.
+func (m *Manager) LoadAndSave(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := m.load(r)
+
+		ctx := context.WithValue(r.Context(), contextKey{}, s)
+
+		sw := &sessionResponseWriter{ResponseWriter: w, manager: m, session: s}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		// Handlers that write no response at all still get their
+		// session saved.
+		sw.commit()
+	})
+}
+
+// sessionResponseWriter wraps the ResponseWriter so the session cookie
+// is set immediately before the response headers are committed, the last
+// point at which a Set-Cookie header still reaches the client.
+type sessionResponseWriter struct {
+	http.ResponseWriter
+	manager   *Manager
+	session   *session
+	committed bool
+}
+
+// commit writes the session cookie if the session was modified. It runs
+// once; later session writes are too late to save and are dropped.
+func (sw *sessionResponseWriter) commit() {
+	if sw.committed {
+		return
+	}
+	sw.committed = true
+
+	if !sw.session.modified {
+		return
+	}
+
+	if err := sw.manager.save(sw.ResponseWriter, sw.session); err != nil {
+		if sw.manager.OnError != nil {
+			sw.manager.OnError(err)
+		}
+	}
+}
+
+func (sw *sessionResponseWriter) WriteHeader(statusCode int) {
+	sw.commit()
+	sw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (sw *sessionResponseWriter) Write(b []byte) (int, error) {
+	sw.commit()
+	return sw.ResponseWriter.Write(b)
+}
+
+// Flush passes through to the underlying writer so streaming responses
+// keep working.
+func (sw *sessionResponseWriter) Flush() {
+	sw.commit()
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack passes through to the underlying writer for WebSocket upgrades.
+func (sw *sessionResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hj, ok := sw.ResponseWriter.(http.Hijacker); ok {
+		sw.committed = true
+		return hj.Hijack()
+	}
+	return nil, nil, errors.New("cookiesession: underlying ResponseWriter does not support hijacking")
+}
+
+// Put adds a key and value to the session data.
+func (m *Manager) Put(ctx context.Context, key string, val any) {
+	s := m.session(ctx)
+	s.values[key] = val
+	s.modified = true
+}
+
+// GetInt returns the int for the key, or zero if absent.
+func (m *Manager) GetInt(ctx context.Context, key string) int {
+	n, _ := m.session(ctx).values[key].(int)
+	return n
+}
+
+// GetString returns the string for the key, or the empty string if
+// absent.
+func (m *Manager) GetString(ctx context.Context, key string) string {
+	str, _ := m.session(ctx).values[key].(string)
+	return str
+}
+
+// PopString returns the string for the key and removes it from the
+// session, or returns the empty string if absent.
+func (m *Manager) PopString(ctx context.Context, key string) string {
+	s := m.session(ctx)
+
+	str, ok := s.values[key].(string)
+	if !ok {
+		return ""
+	}
+
+	delete(s.values, key)
+	s.modified = true
+
+	return str
+}
+
+// Exists reports whether the key is present in the session data.
+func (m *Manager) Exists(ctx context.Context, key string) bool {
+	_, ok := m.session(ctx).values[key]
+	return ok
+}
+
+// Remove deletes the key from the session data.
+func (m *Manager) Remove(ctx context.Context, key string) {
+	s := m.session(ctx)
+	delete(s.values, key)
+	s.modified = true
+}
+
+// RenewToken resets the session deadline. There is no server-side token
+// to rotate: the cookie is re-encrypted with a fresh nonce on every
+// save, so this exists to satisfy the session manager interface.
+func (m *Manager) RenewToken(ctx context.Context) error {
+	s := m.session(ctx)
+	s.deadline = time.Now().UTC().Add(m.lifetime)
+	s.modified = true
+	return nil
+}
+
+// session returns the request's session state, or an empty throwaway one
+// if LoadAndSave did not run (so a misrouted call can't panic).
+func (m *Manager) session(ctx context.Context) *session {
+	s, ok := ctx.Value(contextKey{}).(*session)
+	if !ok {
+		return &session{values: map[string]any{}}
+	}
+	return s
+}
+
+// load decodes and decrypts the session cookie, returning a fresh session
+// when the cookie is missing, expired or undecryptable.
+func (m *Manager) load(r *http.Request) *session {
+	fresh := &session{
+		deadline: time.Now().UTC().Add(m.lifetime),
+		values:   map[string]any{},
+	}
+
+	cookie, err := r.Cookie(m.Name)
+	if err != nil {
+		return fresh
+	}
+
+	box, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(box) < 24 {
+		return fresh
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], box[:24])
+
+	// Try every key, newest first, so cookies encrypted before a key
+	// rotation still decrypt.
+	var plain []byte
+	var ok bool
+	for _, key := range m.keys {
+		plain, ok = secretbox.Open(nil, box[24:], &nonce, &key)
+		if ok {
+			break
+		}
+	}
+	if !ok {
+		return fresh
+	}
+
+	var p payload
+	if err := gob.NewDecoder(bytes.NewReader(plain)).Decode(&p); err != nil {
+		return fresh
+	}
+
+	if time.Now().UTC().After(p.Deadline) {
+		return fresh
+	}
+
+	return &session{deadline: p.Deadline, values: p.Values}
+}
+
+// save encrypts the session and writes it as a cookie. Sessions with no
+// values clear the cookie instead.
+func (m *Manager) save(w http.ResponseWriter, s *session) error {
+	if len(s.values) == 0 {
+		http.SetCookie(w, &http.Cookie{
+			Name:     m.Name,
+			Value:    "",
+			Domain:   m.Domain,
+			Path:     m.Path,
+			MaxAge:   -1,
+			Secure:   !m.Insecure,
+			HttpOnly: true,
+			SameSite: m.SameSite,
+		})
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(payload{Deadline: s.deadline, Values: s.values}); err != nil {
+		return err
+	}
+
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return err
+	}
+
+	box := secretbox.Seal(nonce[:], buf.Bytes(), &nonce, &m.keys[0])
+	value := base64.RawURLEncoding.EncodeToString(box)
+
+	if len(value) > maxCookieSize {
+		return ErrTooLarge
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     m.Name,
+		Value:    value,
+		Domain:   m.Domain,
+		Path:     m.Path,
+		Expires:  s.deadline,
+		Secure:   !m.Insecure,
+		HttpOnly: true,
+		SameSite: m.SameSite,
+	})
+
+	return nil
+}
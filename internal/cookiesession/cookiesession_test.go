@@ -0,0 +1,271 @@
+package cookiesession
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testKey returns a deterministic 32-byte key filled with b.
+func testKey(b byte) []byte {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = b
+	}
+	return key
+}
+
+// newTestManager returns a Manager suitable for plain-HTTP test servers.
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	m, err := New([][]byte{testKey(1)}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Insecure = true
+
+	return m
+}
+
+// get makes one request against a real test server, carrying the given
+// cookie if set, and returns the response. A real server matters here:
+// it commits response headers mid-handler once enough of the body is
+// written, which is exactly the behaviour the save path has to beat.
+func get(t *testing.T, ts *httptest.Server, cookie *http.Cookie) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	return resp
+}
+
+// sessionCookie returns the response's session cookie, or nil.
+func sessionCookie(m *Manager, resp *http.Response) *http.Cookie {
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == m.Name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	m := newTestManager(t)
+
+	write := httptest.NewServer(m.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Put(r.Context(), "flash", "hello")
+		w.Write([]byte("OK"))
+	})))
+	defer write.Close()
+
+	resp := get(t, write, nil)
+	cookie := sessionCookie(m, resp)
+	if cookie == nil {
+		t.Fatal("no session cookie set after a session write")
+	}
+
+	read := httptest.NewServer(m.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := m.GetString(r.Context(), "flash"); got != "hello" {
+			t.Errorf(`GetString("flash") = %q; want %q`, got, "hello")
+		}
+		w.Write([]byte("OK"))
+	})))
+	defer read.Close()
+
+	get(t, read, cookie)
+}
+
+func TestSaveSurvivesLargeBody(t *testing.T) {
+	m := newTestManager(t)
+
+	// A body well past the server's write buffer forces the response
+	// headers out mid-handler, so a session save deferred until after
+	// the handler would be lost.
+	body := strings.Repeat("x", 64*1024)
+
+	ts := httptest.NewServer(m.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Put(r.Context(), "flash", "hello")
+		w.Write([]byte(body))
+	})))
+	defer ts.Close()
+
+	if sessionCookie(m, get(t, ts, nil)) == nil {
+		t.Error("no session cookie set when the handler writes a large body")
+	}
+}
+
+func TestSaveSurvivesRedirect(t *testing.T) {
+	m := newTestManager(t)
+
+	ts := httptest.NewServer(m.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Put(r.Context(), "authenticatedUserID", 1)
+		http.Redirect(w, r, "/elsewhere", http.StatusSeeOther)
+	})))
+	defer ts.Close()
+
+	resp := get(t, ts, nil)
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("got status %d; want %d", resp.StatusCode, http.StatusSeeOther)
+	}
+	if sessionCookie(m, resp) == nil {
+		t.Error("no session cookie set when the handler redirects")
+	}
+}
+
+func TestSessionWriteAfterResponseDropped(t *testing.T) {
+	m := newTestManager(t)
+
+	ts := httptest.NewServer(m.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+		m.Put(r.Context(), "flash", "too late")
+	})))
+	defer ts.Close()
+
+	if sessionCookie(m, get(t, ts, nil)) != nil {
+		t.Error("session cookie set for a write after the response started")
+	}
+}
+
+func TestEmptySessionClearsCookie(t *testing.T) {
+	m := newTestManager(t)
+
+	write := httptest.NewServer(m.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Put(r.Context(), "flash", "hello")
+		w.Write([]byte("OK"))
+	})))
+	defer write.Close()
+
+	cookie := sessionCookie(m, get(t, write, nil))
+	if cookie == nil {
+		t.Fatal("no session cookie set after a session write")
+	}
+
+	pop := httptest.NewServer(m.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.PopString(r.Context(), "flash")
+		w.Write([]byte("OK"))
+	})))
+	defer pop.Close()
+
+	cleared := sessionCookie(m, get(t, pop, cookie))
+	if cleared == nil {
+		t.Fatal("no session cookie set when the last value was removed")
+	}
+	if cleared.Value != "" || cleared.MaxAge >= 0 {
+		t.Errorf("got cookie value %q with MaxAge %d; want an expired empty cookie", cleared.Value, cleared.MaxAge)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	old, err := New([][]byte{testKey(1)}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	err = old.save(rec, &session{
+		deadline: time.Now().UTC().Add(time.Hour),
+		values:   map[string]any{"flash": "hello"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookie := rec.Result().Cookies()[0]
+
+	// A manager with a fresh primary key and the old key second should
+	// still decrypt the old cookie.
+	rotated, err := New([][]byte{testKey(2), testKey(1)}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(cookie)
+
+	s := rotated.load(r)
+	if got := s.values["flash"]; got != "hello" {
+		t.Errorf(`loaded values["flash"] = %v; want %q`, got, "hello")
+	}
+
+	// Without the old key the cookie is undecryptable and the session
+	// starts fresh.
+	stranger, err := New([][]byte{testKey(3)}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := stranger.load(r); len(s.values) != 0 {
+		t.Errorf("load with the wrong key returned values %v; want none", s.values)
+	}
+}
+
+func TestExpiredSession(t *testing.T) {
+	m := newTestManager(t)
+
+	rec := httptest.NewRecorder()
+	err := m.save(rec, &session{
+		deadline: time.Now().UTC().Add(-time.Minute),
+		values:   map[string]any{"flash": "hello"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(rec.Result().Cookies()[0])
+
+	if s := m.load(r); len(s.values) != 0 {
+		t.Errorf("load of an expired session returned values %v; want none", s.values)
+	}
+}
+
+func TestTooLarge(t *testing.T) {
+	m := newTestManager(t)
+
+	err := m.save(httptest.NewRecorder(), &session{
+		deadline: time.Now().UTC().Add(time.Hour),
+		values:   map[string]any{"blob": strings.Repeat("x", maxCookieSize)},
+	})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("save of an oversized session returned %v; want ErrTooLarge", err)
+	}
+
+	// Through the middleware the error reaches OnError and no cookie is
+	// written.
+	var reported error
+	m.OnError = func(err error) { reported = err }
+
+	ts := httptest.NewServer(m.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Put(r.Context(), "blob", strings.Repeat("x", maxCookieSize))
+		w.Write([]byte("OK"))
+	})))
+	defer ts.Close()
+
+	resp := get(t, ts, nil)
+	if !errors.Is(reported, ErrTooLarge) {
+		t.Errorf("OnError got %v; want ErrTooLarge", reported)
+	}
+	if sessionCookie(m, resp) != nil {
+		t.Error("session cookie set for an oversized session")
+	}
+}
@@ -0,0 +1,104 @@
+package feed
+
+import (
+	"sync"
+	"time"
+)
+
+// CommentEvent describes a newly posted comment in the form streamed to
+// subscribers of the snippet it belongs to.
+type CommentEvent struct {
+	ID        int       `json:"id"`
+	SnippetID int       `json:"snippetId"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	Created   time.Time `json:"created"`
+}
+
+// CommentHub fans out comment events to subscribers, keyed by snippet ID.
+// Unlike Hub it is mutex-based, because subscriptions are partitioned per
+// snippet and there is no shared broadcast loop.
+type CommentHub struct {
+	mu          sync.Mutex
+	closed      bool
+	subscribers map[int]map[chan CommentEvent]struct{}
+}
+
+// NewCommentHub returns an initialized CommentHub.
+func NewCommentHub() *CommentHub {
+	return &CommentHub{
+		subscribers: map[int]map[chan CommentEvent]struct{}{},
+	}
+}
+
+// Subscribe registers a subscriber for comments on the given snippet and
+// returns the channel its events will be delivered on. If the hub has
+// already been closed the returned channel is closed immediately.
+func (h *CommentHub) Subscribe(snippetID int) chan CommentEvent {
+	ch := make(chan CommentEvent, 8)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(ch)
+		return ch
+	}
+
+	if h.subscribers[snippetID] == nil {
+		h.subscribers[snippetID] = map[chan CommentEvent]struct{}{}
+	}
+	h.subscribers[snippetID][ch] = struct{}{}
+
+	return ch
+}
+
+// Unsubscribe removes a subscriber previously returned by Subscribe and
+// closes its channel.
+func (h *CommentHub) Unsubscribe(snippetID int, ch chan CommentEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if subs, ok := h.subscribers[snippetID]; ok {
+		if _, ok := subs[ch]; ok {
+			delete(subs, ch)
+			close(ch)
+		}
+		if len(subs) == 0 {
+			delete(h.subscribers, snippetID)
+		}
+	}
+}
+
+// Publish broadcasts an event to all subscribers of its snippet. Slow
+// subscribers have events dropped rather than blocking the publisher.
+func (h *CommentHub) Publish(event CommentEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[event.SnippetID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Close closes every subscriber channel so that in-flight streaming
+// handlers return, allowing the server to shut down gracefully.
+func (h *CommentHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+
+	for snippetID, subs := range h.subscribers {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(h.subscribers, snippetID)
+	}
+}
@@ -0,0 +1,77 @@
+// Package feed implements an in-process publish/subscribe hub used to push
+// newly created public snippets to connected clients.
+package feed
+
+import "time"
+
+// Event describes a newly created snippet in the form broadcast to
+// subscribers.
+type Event struct {
+	ID      int       `json:"id"`
+	Title   string    `json:"title"`
+	Created time.Time `json:"created"`
+}
+
+// Hub fans out events to the currently subscribed clients. Register,
+// unregister and broadcast operations are serialized through channels by the
+// Run loop, so Hub is safe for concurrent use.
+type Hub struct {
+	subscribers map[chan Event]struct{}
+	register    chan chan Event
+	unregister  chan chan Event
+	broadcast   chan Event
+}
+
+// NewHub returns an initialized Hub. The caller must start the Run loop in
+// its own goroutine before publishing or subscribing.
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: map[chan Event]struct{}{},
+		register:    make(chan chan Event),
+		unregister:  make(chan chan Event),
+		broadcast:   make(chan Event, 64),
+	}
+}
+
+// Run processes subscriptions and broadcasts until the hub is no longer
+// needed. Slow subscribers that cannot keep up have events dropped rather
+// than blocking the hub.
+func (h *Hub) Run() {
+	for {
+		select {
+		case ch := <-h.register:
+			h.subscribers[ch] = struct{}{}
+		case ch := <-h.unregister:
+			if _, ok := h.subscribers[ch]; ok {
+				delete(h.subscribers, ch)
+				close(ch)
+			}
+		case event := <-h.broadcast:
+			for ch := range h.subscribers {
+				select {
+				case ch <- event:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// Subscribe registers a new subscriber and returns the channel its events
+// will be delivered on.
+func (h *Hub) Subscribe() chan Event {
+	ch := make(chan Event, 8)
+	h.register <- ch
+	return ch
+}
+
+// Unsubscribe removes a subscriber previously returned by Subscribe and
+// closes its channel.
+func (h *Hub) Unsubscribe(ch chan Event) {
+	h.unregister <- ch
+}
+
+// Publish broadcasts an event to all current subscribers.
+func (h *Hub) Publish(event Event) {
+	h.broadcast <- event
+}
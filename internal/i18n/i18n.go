@@ -0,0 +1,129 @@
+// Package i18n loads the embedded message catalogs and picks the best
+// supported locale for a request.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed locales/*.json
+var files embed.FS
+
+// fallback is the locale every message must exist in; it is used
+// whenever a translation is missing or no better match is found.
+const fallback = "en"
+
+// Translator holds the message catalogs, keyed by locale and then by
+// message key.
+type Translator struct {
+	messages map[string]map[string]string
+	matcher  language.Matcher
+	locales  []string
+}
+
+// New loads every catalog under locales/. Each file is named after its
+// locale (en.json, de.json, ...) and holds a flat map of message keys
+// to fmt format strings.
+func New() (*Translator, error) {
+	names, err := fs.Glob(files, "locales/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Translator{messages: map[string]map[string]string{}}
+
+	for _, name := range names {
+		locale := strings.TrimSuffix(filepath.Base(name), ".json")
+
+		data, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		messages := map[string]string{}
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("i18n: %s: %w", name, err)
+		}
+
+		t.messages[locale] = messages
+		t.locales = append(t.locales, locale)
+	}
+
+	if _, ok := t.messages[fallback]; !ok {
+		return nil, fmt.Errorf("i18n: no %s catalog", fallback)
+	}
+
+	// The matcher prefers earlier tags on a tie, so the fallback goes
+	// first and the rest stay alphabetical.
+	sort.Slice(t.locales, func(i, j int) bool {
+		if t.locales[i] == fallback {
+			return true
+		}
+		if t.locales[j] == fallback {
+			return false
+		}
+		return t.locales[i] < t.locales[j]
+	})
+
+	tags := make([]language.Tag, len(t.locales))
+	for i, locale := range t.locales {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return nil, fmt.Errorf("i18n: %s: %w", locale, err)
+		}
+		tags[i] = tag
+	}
+	t.matcher = language.NewMatcher(tags)
+
+	return t, nil
+}
+
+// Locales returns the supported locale codes, fallback first.
+func (t *Translator) Locales() []string {
+	return t.locales
+}
+
+// Supported reports whether a catalog exists for the locale.
+func (t *Translator) Supported(locale string) bool {
+	_, ok := t.messages[locale]
+	return ok
+}
+
+// Match returns the best supported locale for an Accept-Language
+// header, falling back to English.
+func (t *Translator) Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return fallback
+	}
+
+	_, i, _ := t.matcher.Match(tags...)
+
+	return t.locales[i]
+}
+
+// T returns the message for key in the given locale, formatted with
+// args. A missing translation falls back to English, and an unknown
+// key is returned as-is so the page still renders.
+func (t *Translator) T(locale, key string, args ...any) string {
+	msg, ok := t.messages[locale][key]
+	if !ok {
+		msg, ok = t.messages[fallback][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
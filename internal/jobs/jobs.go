@@ -0,0 +1,135 @@
+// Package jobs provides a small in-process scheduler that runs registered
+// jobs at fixed intervals, tracking when each job last ran and whether it
+// failed, so background maintenance work (purges, digests, retries) lives
+// in one place with visible status.
+package jobs
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Status describes a registered job and the outcome of its most recent
+// run. LastError is empty when the last run succeeded, and LastRun is the
+// zero time until the job has run at least once.
+type Status struct {
+	Name      string
+	Interval  time.Duration
+	LastRun   time.Time
+	LastError string
+	Runs      int
+}
+
+// job pairs a registered function with its bookkeeping.
+type job struct {
+	name     string
+	interval time.Duration
+	run      func() error
+
+	mu        sync.Mutex
+	lastRun   time.Time
+	lastError string
+	runs      int
+}
+
+// Scheduler runs registered jobs on their intervals until stopped. Jobs
+// must be registered before Start is called.
+type Scheduler struct {
+	// OnError, if set, is called whenever a job run returns an error or
+	// panics.
+	OnError func(name string, err error)
+
+	jobs []*job
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewScheduler returns an empty scheduler.
+func NewScheduler() *Scheduler {
+	return &Scheduler{stop: make(chan struct{})}
+}
+
+// Register adds a job to be run every interval once the scheduler starts.
+func (s *Scheduler) Register(name string, interval time.Duration, run func() error) {
+	s.jobs = append(s.jobs, &job{name: name, interval: interval, run: run})
+}
+
+// Start launches a goroutine per registered job.
+func (s *Scheduler) Start() {
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(j)
+	}
+}
+
+// Stop tells every job goroutine to exit and waits for them. A run already
+// in progress completes first.
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// Status returns the current state of every registered job, in
+// registration order.
+func (s *Scheduler) Status() []Status {
+	status := make([]Status, len(s.jobs))
+	for i, j := range s.jobs {
+		j.mu.Lock()
+		status[i] = Status{
+			Name:      j.name,
+			Interval:  j.interval,
+			LastRun:   j.lastRun,
+			LastError: j.lastError,
+			Runs:      j.runs,
+		}
+		j.mu.Unlock()
+	}
+	return status
+}
+
+// loop runs a single job on its interval until the scheduler is stopped.
+func (s *Scheduler) loop(j *job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.runOnce(j)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// runOnce executes the job, recovering from panics so one bad job can't
+// take the process down, and records the outcome.
+func (s *Scheduler) runOnce(j *job) {
+	var err error
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		err = j.run()
+	}()
+
+	j.mu.Lock()
+	j.lastRun = time.Now()
+	j.runs++
+	if err != nil {
+		j.lastError = err.Error()
+	} else {
+		j.lastError = ""
+	}
+	j.mu.Unlock()
+
+	if err != nil && s.OnError != nil {
+		s.OnError(j.name, err)
+	}
+}
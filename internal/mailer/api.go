@@ -0,0 +1,21 @@
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// newAPIClient returns the HTTP client the API transports share, with
+// the same timeout the SMTP transport applies to its sends.
+func newAPIClient() *http.Client {
+	return &http.Client{Timeout: sendTimeout}
+}
+
+// apiError turns a non-success provider response into an error carrying
+// the status and the start of the response body, which is where the
+// providers put their diagnostic message.
+func apiError(provider string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("mailer: %s responded %s: %s", provider, resp.Status, body)
+}
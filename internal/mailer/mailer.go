@@ -0,0 +1,99 @@
+// Package mailer sends multipart text and HTML emails, rendered from
+// its own embedded template set. Each template file defines three named
+// templates — "subject", "plainBody" and "htmlBody" — which together
+// make up one message.
+//
+// Delivery goes through a Transport; implementations exist for plain
+// SMTP and for the Amazon SES, SendGrid and Mailgun HTTP APIs, so
+// deployments can use whichever provider they already have.
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"text/template"
+	"time"
+)
+
+//go:embed "templates"
+var templateFS embed.FS
+
+// sendTimeout bounds how long a send waits on the mail provider, so a
+// dead mail host can't hang the caller indefinitely.
+const sendTimeout = 10 * time.Second
+
+// Transport delivers one rendered email. The sender and recipient may
+// carry display names in the usual "Name <address>" form.
+type Transport interface {
+	Send(sender, recipient, subject string, plainBody, htmlBody []byte) error
+}
+
+// Mailer renders emails from the embedded templates and hands them to
+// its transport. The zero value is not usable; construct one with
+// NewSMTP, NewSES, NewSendGrid or NewMailgun.
+type Mailer struct {
+	sender    string
+	transport Transport
+}
+
+// Send renders the named template file and emails the result to
+// recipient. It blocks until the provider has accepted the message, so
+// callers on a request path should send from a background task.
+func (m *Mailer) Send(recipient, templateFile string, data any) error {
+	subject, plainBody, htmlBody, err := Render(templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	return m.transport.Send(m.sender, recipient, subject, plainBody, htmlBody)
+}
+
+// Templates lists the embedded email template files, for the dev
+// preview index.
+func Templates() ([]string, error) {
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names, nil
+}
+
+// Render renders the named template file without sending anything,
+// returning the subject line and both body parts. The subject and plain
+// body render as plain text while the HTML body gets contextual
+// escaping.
+func Render(templateFile string, data any) (subject string, plainBody, htmlBody []byte, err error) {
+	textTmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	htmlTmpl, err := htmltemplate.New("email").ParseFS(templateFS, "templates/"+templateFile)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	subjectBuf := new(bytes.Buffer)
+	if err := textTmpl.ExecuteTemplate(subjectBuf, "subject", data); err != nil {
+		return "", nil, nil, err
+	}
+
+	plainBuf := new(bytes.Buffer)
+	if err := textTmpl.ExecuteTemplate(plainBuf, "plainBody", data); err != nil {
+		return "", nil, nil, err
+	}
+
+	htmlBuf := new(bytes.Buffer)
+	if err := htmlTmpl.ExecuteTemplate(htmlBuf, "htmlBody", data); err != nil {
+		return "", nil, nil, err
+	}
+
+	return subjectBuf.String(), plainBuf.Bytes(), htmlBuf.Bytes(), nil
+}
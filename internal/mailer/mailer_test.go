@@ -0,0 +1,113 @@
+package mailer
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSendGridTransport(t *testing.T) {
+	var (
+		gotAuth string
+		gotBody map[string]any
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+			t.Errorf("decoding request body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	transport := &sendgridTransport{apiKey: "sg-key", endpoint: ts.URL, client: ts.Client()}
+
+	err := transport.Send("Snippetbox <no-reply@example.com>", "alice@example.com", "Hello", []byte("plain"), []byte("<p>html</p>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotAuth != "Bearer sg-key" {
+		t.Errorf("got Authorization %q; want %q", gotAuth, "Bearer sg-key")
+	}
+	if got := gotBody["subject"]; got != "Hello" {
+		t.Errorf("got subject %q; want %q", got, "Hello")
+	}
+}
+
+func TestMailgunTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "api" || password != "mg-key" {
+			t.Errorf("got basic auth %q/%q ok=%v; want api/mg-key", username, password, ok)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parsing request form: %v", err)
+		}
+		if got := r.PostForm.Get("to"); got != "alice@example.com" {
+			t.Errorf("got to %q; want %q", got, "alice@example.com")
+		}
+	}))
+	defer ts.Close()
+
+	transport := &mailgunTransport{apiKey: "mg-key", endpoint: ts.URL, client: ts.Client()}
+
+	err := transport.Send("no-reply@example.com", "alice@example.com", "Hello", []byte("plain"), []byte("<p>html</p>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMailgunTransportError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Forbidden", http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	transport := &mailgunTransport{apiKey: "bad", endpoint: ts.URL, client: ts.Client()}
+
+	err := transport.Send("no-reply@example.com", "alice@example.com", "Hello", nil, nil)
+	if err == nil {
+		t.Fatal("got nil error; want provider error")
+	}
+	if !strings.Contains(err.Error(), "401") {
+		t.Errorf("got error %q; want it to mention the 401 status", err)
+	}
+}
+
+func TestSESSign(t *testing.T) {
+	var gotAuth, gotDate string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotDate = r.Header.Get("X-Amz-Date")
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer ts.Close()
+
+	transport := &sesTransport{
+		region:    "eu-west-1",
+		accessKey: "AKIAEXAMPLE",
+		secretKey: "secret",
+		endpoint:  ts.URL + "/v2/email/outbound-emails",
+		client:    ts.Client(),
+	}
+
+	err := transport.Send("no-reply@example.com", "alice@example.com", "Hello", []byte("plain"), []byte("<p>html</p>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authRX := regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=AKIAEXAMPLE/\d{8}/eu-west-1/ses/aws4_request, SignedHeaders=host;x-amz-date, Signature=[0-9a-f]{64}$`)
+	if !authRX.MatchString(gotAuth) {
+		t.Errorf("got Authorization %q; want it to match %s", gotAuth, authRX)
+	}
+	if gotDate == "" {
+		t.Error("got empty X-Amz-Date header")
+	}
+}
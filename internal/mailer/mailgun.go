@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// mailgunBase is the Mailgun US-region API root; the sending domain's
+// messages endpoint hangs off it.
+const mailgunBase = "https://api.mailgun.net/v3"
+
+// mailgunTransport delivers through the Mailgun messages API.
+type mailgunTransport struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+}
+
+// NewMailgun returns a Mailer that delivers through the Mailgun API for
+// the given sending domain.
+func NewMailgun(domain, apiKey, sender string) *Mailer {
+	return &Mailer{
+		sender: sender,
+		transport: &mailgunTransport{
+			apiKey:   apiKey,
+			endpoint: mailgunBase + "/" + domain + "/messages",
+			client:   newAPIClient(),
+		},
+	}
+}
+
+// Send posts the message form-encoded to the domain's messages
+// endpoint, authenticating with HTTP basic auth as Mailgun expects.
+func (t *mailgunTransport) Send(sender, recipient, subject string, plainBody, htmlBody []byte) error {
+	form := url.Values{}
+	form.Set("from", sender)
+	form.Set("to", recipient)
+	form.Set("subject", subject)
+	form.Set("text", string(plainBody))
+	form.Set("html", string(htmlBody))
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth("api", t.apiKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return apiError("mailgun", resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/mail"
+)
+
+// sendgridEndpoint is the SendGrid v3 mail send endpoint.
+const sendgridEndpoint = "https://api.sendgrid.com/v3/mail/send"
+
+// sendgridTransport delivers through the SendGrid v3 API.
+type sendgridTransport struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+}
+
+// NewSendGrid returns a Mailer that delivers through the SendGrid API
+// using the given API key.
+func NewSendGrid(apiKey, sender string) *Mailer {
+	return &Mailer{
+		sender: sender,
+		transport: &sendgridTransport{
+			apiKey:   apiKey,
+			endpoint: sendgridEndpoint,
+			client:   newAPIClient(),
+		},
+	}
+}
+
+// sendgridAddress is an email address in SendGrid's request format.
+type sendgridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+// Send posts the message to the mail send endpoint. SendGrid replies
+// 202 Accepted on success.
+func (t *sendgridTransport) Send(sender, recipient, subject string, plainBody, htmlBody []byte) error {
+	from, err := mail.ParseAddress(sender)
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(recipient)
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{
+		"personalizations": []map[string]any{
+			{"to": []sendgridAddress{{Email: to.Address, Name: to.Name}}},
+		},
+		"from":    sendgridAddress{Email: from.Address, Name: from.Name},
+		"subject": subject,
+		"content": []map[string]string{
+			{"type": "text/plain", "value": string(plainBody)},
+			{"type": "text/html", "value": string(htmlBody)},
+		},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return apiError("sendgrid", resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+)
+
+// smtpTransport submits messages to an SMTP server.
+type smtpTransport struct {
+	host     string
+	port     int
+	username string
+	password string
+	tls      bool
+}
+
+// NewSMTP returns a Mailer that delivers through the given SMTP server.
+// The username and password may be empty for servers that accept
+// unauthenticated mail; implicitTLS selects a TLS connection from the
+// start (typically port 465) instead of upgrading with STARTTLS when
+// the server offers it.
+func NewSMTP(host string, port int, username, password, sender string, implicitTLS bool) *Mailer {
+	return &Mailer{
+		sender: sender,
+		transport: &smtpTransport{
+			host:     host,
+			port:     port,
+			username: username,
+			password: password,
+			tls:      implicitTLS,
+		},
+	}
+}
+
+// Send connects to the server, negotiates TLS and authentication as
+// configured, and submits the assembled MIME message. The envelope uses
+// the bare addresses, so the sender and recipient may carry display
+// names.
+func (t *smtpTransport) Send(sender, recipient, subject string, plainBody, htmlBody []byte) error {
+	from, err := mail.ParseAddress(sender)
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(recipient)
+	if err != nil {
+		return err
+	}
+
+	msg, err := buildMessage(sender, recipient, subject, plainBody, htmlBody)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(t.host, strconv.Itoa(t.port))
+
+	var client *smtp.Client
+
+	if t.tls {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: sendTimeout}, "tcp", addr, &tls.Config{ServerName: t.host})
+		if err != nil {
+			return err
+		}
+		client, err = smtp.NewClient(conn, t.host)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+	} else {
+		conn, err := net.DialTimeout("tcp", addr, sendTimeout)
+		if err != nil {
+			return err
+		}
+		client, err = smtp.NewClient(conn, t.host)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: t.host}); err != nil {
+				client.Close()
+				return err
+			}
+		}
+	}
+	defer client.Close()
+
+	if t.username != "" {
+		auth := smtp.PlainAuth("", t.username, t.password, t.host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// buildMessage assembles a multipart/alternative MIME message with the
+// plain part first, per RFC 2046's least-preferred-first ordering.
+func buildMessage(sender, recipient, subject string, plainBody, htmlBody []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=\"utf-8\"", plainBody},
+		{"text/html; charset=\"utf-8\"", htmlBody},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {part.contentType}})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := pw.Write(part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(buf, "From: %s\r\n", sender)
+	fmt.Fprintf(buf, "To: %s\r\n", recipient)
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(buf, "Content-Type: multipart/alternative; boundary=%q\r\n", mw.Boundary())
+	fmt.Fprintf(buf, "\r\n")
+	buf.Write(body.Bytes())
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,28 @@
+// Package markdown renders user-supplied Markdown to HTML that is safe
+// to embed in a page. Rendering and sanitising are separate steps: the
+// goldmark output is run through a bluemonday policy, so raw HTML in
+// the source — however it got there — never reaches the browser.
+package markdown
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	renderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	policy   = bluemonday.UGCPolicy()
+)
+
+// Render converts src from Markdown to sanitized HTML.
+func Render(src string) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+	return template.HTML(policy.SanitizeBytes(buf.Bytes())), nil
+}
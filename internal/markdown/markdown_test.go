@@ -0,0 +1,26 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	got, err := Render("Some **bold** text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "<strong>bold</strong>") {
+		t.Errorf("Render() = %q; want bold markup", got)
+	}
+}
+
+func TestRenderSanitizes(t *testing.T) {
+	got, err := Render("hello <script>alert(1)</script> [x](javascript:alert(1))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(got), "<script") || strings.Contains(string(got), "javascript:") {
+		t.Errorf("Render() = %q; want script and javascript: URLs stripped", got)
+	}
+}
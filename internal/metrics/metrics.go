@@ -0,0 +1,113 @@
+// Package metrics collects Prometheus instrumentation for the web
+// application: HTTP request counts and latencies, template render times and
+// database query durations.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics owns the application's Prometheus registry and collectors.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	// RequestsTotal counts completed HTTP requests by method, route and
+	// status code.
+	RequestsTotal *prometheus.CounterVec
+
+	// RequestDuration records HTTP request latency by method and route.
+	RequestDuration *prometheus.HistogramVec
+
+	// RequestsInFlight tracks the number of requests currently being
+	// served.
+	RequestsInFlight prometheus.Gauge
+
+	renderDuration *prometheus.HistogramVec
+	queryDuration  *prometheus.HistogramVec
+	purgedRows     *prometheus.CounterVec
+}
+
+// New returns a Metrics value with all collectors registered on a fresh
+// registry, along with the standard Go runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		RequestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "snippetbox_http_requests_total",
+			Help: "Total number of HTTP requests completed.",
+		}, []string{"method", "route", "status"}),
+		RequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "snippetbox_http_request_duration_seconds",
+			Help:    "HTTP request latencies in seconds.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		RequestsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "snippetbox_http_requests_in_flight",
+			Help: "Number of HTTP requests currently being served.",
+		}),
+		renderDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "snippetbox_template_render_duration_seconds",
+			Help:    "Template render times in seconds, by page.",
+			Buckets: []float64{.0001, .0005, .001, .005, .01, .05, .1},
+		}, []string{"page"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "snippetbox_db_query_duration_seconds",
+			Help:    "Database query durations in seconds, by statement name.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"query"}),
+		purgedRows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "snippetbox_purged_rows_total",
+			Help: "Total number of expired rows removed by the background purge, by table.",
+		}, []string{"table"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.RequestsTotal,
+		m.RequestDuration,
+		m.RequestsInFlight,
+		m.renderDuration,
+		m.queryDuration,
+		m.purgedRows,
+	)
+
+	return m
+}
+
+// Handler returns an http.Handler exposing the registry in the Prometheus
+// text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRender records the time taken to render the named page template.
+func (m *Metrics) ObserveRender(page string, d time.Duration) {
+	m.renderDuration.WithLabelValues(page).Observe(d.Seconds())
+}
+
+// ObserveQuery records the duration of the named database query.
+func (m *Metrics) ObserveQuery(name string, d time.Duration) {
+	m.queryDuration.WithLabelValues(name).Observe(d.Seconds())
+}
+
+// AddPurged records rows removed from the named table by the background
+// purge.
+func (m *Metrics) AddPurged(table string, n int64) {
+	m.purgedRows.WithLabelValues(table).Add(float64(n))
+}
+
+// RegisterGaugeFunc registers a gauge whose value is computed by fn at
+// scrape time, used for values sampled from elsewhere such as the session
+// store.
+func (m *Metrics) RegisterGaugeFunc(name, help string, fn func() float64) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: name,
+		Help: help,
+	}, fn))
+}
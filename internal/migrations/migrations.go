@@ -0,0 +1,237 @@
+// Package migrations manages the database schema with versioned SQL
+// migrations embedded in the binary, replacing manual schema management.
+// Each migration is a pair of sql/<dialect>/NNNN_name.up.sql and .down.sql
+// files; applied versions are tracked in a schema_migrations table.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql
+var files embed.FS
+
+// Migration describes a single schema version and whether it has been
+// applied to the database.
+type Migration struct {
+	Version int
+	Name    string
+	Applied bool
+}
+
+// migration is the parsed form of an embedded up/down file pair.
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// Status returns every known migration for the dialect, oldest first,
+// marked with whether it has been applied.
+func Status(db *sql.DB, dialect string) ([]Migration, error) {
+	ms, err := load(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]Migration, len(ms))
+	for i, m := range ms {
+		status[i] = Migration{Version: m.version, Name: m.name, Applied: applied[m.version]}
+	}
+
+	return status, nil
+}
+
+// Up applies every pending migration in order and returns how many were
+// applied.
+func Up(db *sql.DB, dialect string) (int, error) {
+	ms, err := load(dialect)
+	if err != nil {
+		return 0, err
+	}
+
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, m := range ms {
+		if applied[m.version] {
+			continue
+		}
+
+		if err := apply(db, m.up); err != nil {
+			return n, fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+		}
+
+		_, err = db.Exec("INSERT INTO schema_migrations (version, name, applied) VALUES (?, ?, ?)", m.version, m.name, time.Now().UTC())
+		if err != nil {
+			return n, err
+		}
+
+		n++
+	}
+
+	return n, nil
+}
+
+// Down rolls back the most recently applied migration and returns how many
+// were rolled back (zero when the database is already empty).
+func Down(db *sql.DB, dialect string) (int, error) {
+	ms, err := load(dialect)
+	if err != nil {
+		return 0, err
+	}
+
+	applied, err := appliedVersions(db)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := len(ms) - 1; i >= 0; i-- {
+		m := ms[i]
+		if !applied[m.version] {
+			continue
+		}
+
+		if err := apply(db, m.down); err != nil {
+			return 0, fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+		}
+
+		_, err = db.Exec("DELETE FROM schema_migrations WHERE version = ?", m.version)
+		if err != nil {
+			return 0, err
+		}
+
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+// load parses the embedded migration files for the dialect into ordered
+// up/down pairs, verifying that every version has both directions.
+func load(dialect string) ([]*migration, error) {
+	dir := path.Join("sql", dialect)
+
+	entries, err := files.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrations: unknown dialect %q", dialect)
+	}
+
+	byVersion := map[int]*migration{}
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migrations: unexpected file %s", name)
+		}
+
+		base := strings.TrimSuffix(strings.TrimSuffix(name, ".up.sql"), ".down.sql")
+		version, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migrations: unexpected file %s", name)
+		}
+
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			return nil, fmt.Errorf("migrations: unexpected file %s", name)
+		}
+
+		data, err := files.ReadFile(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[v]
+		if m == nil {
+			m = &migration{version: v, name: label}
+			byVersion[v] = m
+		}
+
+		if direction == "up" {
+			m.up = string(data)
+		} else {
+			m.down = string(data)
+		}
+	}
+
+	ms := make([]*migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migrations: version %04d is missing an up or down file", m.version)
+		}
+		ms = append(ms, m)
+	}
+
+	sort.Slice(ms, func(i, j int) bool { return ms[i].version < ms[j].version })
+
+	return ms, nil
+}
+
+// appliedVersions creates the schema_migrations table if needed and
+// returns the set of versions recorded in it.
+func appliedVersions(db *sql.DB) (map[int]bool, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]bool{}
+
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		applied[v] = true
+	}
+
+	return applied, rows.Err()
+}
+
+// apply executes the statements in a migration file one at a time, since
+// the MySQL driver doesn't allow multiple statements per Exec by default.
+func apply(db *sql.DB, script string) error {
+	for _, stmt := range strings.Split(script, ";") {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" {
+			continue
+		}
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
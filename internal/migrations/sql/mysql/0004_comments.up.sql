@@ -0,0 +1,9 @@
+CREATE TABLE comments (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    snippet_id INTEGER NOT NULL REFERENCES snippets(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    body TEXT NOT NULL,
+    created DATETIME NOT NULL
+);
+
+CREATE INDEX idx_comments_snippet_id ON comments(snippet_id);
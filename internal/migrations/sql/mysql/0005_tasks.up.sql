@@ -0,0 +1,10 @@
+CREATE TABLE tasks (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    kind VARCHAR(100) NOT NULL,
+    payload BLOB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    run_at DATETIME NOT NULL,
+    created DATETIME NOT NULL
+);
+
+CREATE INDEX idx_tasks_run_at ON tasks(run_at);
@@ -0,0 +1,9 @@
+CREATE TABLE contact_messages (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    created DATETIME NOT NULL
+);
+
+CREATE INDEX idx_contact_messages_created ON contact_messages(created);
@@ -0,0 +1,7 @@
+CREATE TABLE notification_prefs (
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    event VARCHAR(32) NOT NULL,
+    in_app BOOLEAN NOT NULL DEFAULT 1,
+    email BOOLEAN NOT NULL DEFAULT 1,
+    PRIMARY KEY (user_id, event)
+);
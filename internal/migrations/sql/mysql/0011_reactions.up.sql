@@ -0,0 +1,7 @@
+CREATE TABLE reactions (
+    snippet_id INTEGER NOT NULL REFERENCES snippets(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    emoji VARCHAR(16) NOT NULL,
+    created DATETIME NOT NULL,
+    PRIMARY KEY (snippet_id, user_id, emoji)
+);
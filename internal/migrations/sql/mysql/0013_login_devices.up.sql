@@ -0,0 +1,9 @@
+CREATE TABLE login_devices (
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    ip VARCHAR(45) NOT NULL,
+    ua_hash CHAR(64) NOT NULL,
+    user_agent TEXT NOT NULL,
+    first_seen DATETIME NOT NULL,
+    last_seen DATETIME NOT NULL,
+    PRIMARY KEY (user_id, ip, ua_hash)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    hashed_password TEXT NOT NULL,
+    created DATETIME NOT NULL,
+    admin BOOLEAN NOT NULL DEFAULT 0
+);
+
+CREATE UNIQUE INDEX users_uc_email ON users(email);
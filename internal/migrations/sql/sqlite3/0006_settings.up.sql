@@ -0,0 +1,5 @@
+CREATE TABLE settings (
+    name TEXT NOT NULL PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated DATETIME NOT NULL
+);
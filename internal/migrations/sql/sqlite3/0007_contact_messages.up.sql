@@ -0,0 +1,9 @@
+CREATE TABLE contact_messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    message TEXT NOT NULL,
+    created DATETIME NOT NULL
+);
+
+CREATE INDEX idx_contact_messages_created ON contact_messages(created);
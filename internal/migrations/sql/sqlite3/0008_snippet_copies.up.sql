@@ -0,0 +1 @@
+ALTER TABLE snippets ADD COLUMN copies INTEGER NOT NULL DEFAULT 0;
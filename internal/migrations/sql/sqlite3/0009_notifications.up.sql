@@ -0,0 +1,11 @@
+CREATE TABLE notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    kind TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    snippet_id INTEGER NOT NULL DEFAULT 0,
+    created DATETIME NOT NULL,
+    read_at DATETIME
+);
+
+CREATE INDEX idx_notifications_user_id ON notifications(user_id, id);
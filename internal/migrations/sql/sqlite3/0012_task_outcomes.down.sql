@@ -0,0 +1,3 @@
+ALTER TABLE tasks DROP COLUMN done_at;
+ALTER TABLE tasks DROP COLUMN dead_at;
+ALTER TABLE tasks DROP COLUMN last_error;
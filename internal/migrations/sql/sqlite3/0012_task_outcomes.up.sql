@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN done_at DATETIME NULL;
+ALTER TABLE tasks ADD COLUMN dead_at DATETIME NULL;
+ALTER TABLE tasks ADD COLUMN last_error TEXT NULL;
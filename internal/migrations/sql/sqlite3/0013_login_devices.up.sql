@@ -0,0 +1,9 @@
+CREATE TABLE login_devices (
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    ip TEXT NOT NULL,
+    ua_hash TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    first_seen DATETIME NOT NULL,
+    last_seen DATETIME NOT NULL,
+    PRIMARY KEY (user_id, ip, ua_hash)
+);
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Comment represents an individual comment on a snippet, along with the
+// name of the user who wrote it.
+type Comment struct {
+	ID        int
+	SnippetID int
+	UserID    int
+	Author    string
+	Body      string
+	Created   time.Time
+}
+
+// CommentModel wraps a sql.DB connection pool and provides methods for
+// working with the comments table.
+type CommentModel struct {
+	DB *sql.DB
+
+	// ReadDB, if set, is a read-replica pool that Get and ForSnippet
+	// prefer over DB, falling back to DB when the replica is unavailable.
+	ReadDB *sql.DB
+
+	// OnQuery, if set, is called with the name and duration of every
+	// query this model runs.
+	OnQuery QueryHook
+}
+
+// Insert adds a new comment to the given snippet and returns the comment's
+// ID.
+func (m *CommentModel) Insert(snippetID, userID int, body string) (int, error) {
+	defer instrument(m.OnQuery, "comments.Insert", time.Now(), snippetID, userID)
+
+	stmt := `INSERT INTO comments (snippet_id, user_id, body, created)
+	VALUES(?, ?, ?, ?)`
+
+	result, err := m.DB.Exec(stmt, snippetID, userID, body, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Get returns the comment with the given ID.
+func (m *CommentModel) Get(id int) (*Comment, error) {
+	defer instrument(m.OnQuery, "comments.Get", time.Now(), id)
+
+	stmt := `SELECT c.id, c.snippet_id, c.user_id, u.name, c.body, c.created
+	FROM comments AS c INNER JOIN users AS u ON c.user_id = u.id
+	WHERE c.id = ?`
+
+	return readQuery(m.DB, m.ReadDB, func(db *sql.DB) (*Comment, error) {
+		c := &Comment{}
+
+		err := db.QueryRow(stmt, id).Scan(&c.ID, &c.SnippetID, &c.UserID, &c.Author, &c.Body, &c.Created)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrNoRecord
+			}
+			return nil, err
+		}
+
+		return c, nil
+	})
+}
+
+// Commenters returns the distinct IDs of the users who have commented on
+// the given snippet.
+func (m *CommentModel) Commenters(snippetID int) ([]int, error) {
+	defer instrument(m.OnQuery, "comments.Commenters", time.Now(), snippetID)
+
+	stmt := `SELECT DISTINCT user_id FROM comments WHERE snippet_id = ?`
+
+	return readQuery(m.DB, m.ReadDB, func(db *sql.DB) ([]int, error) {
+		rows, err := db.Query(stmt, snippetID)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		ids := []int{}
+
+		for rows.Next() {
+			var id int
+
+			if err = rows.Scan(&id); err != nil {
+				return nil, err
+			}
+
+			ids = append(ids, id)
+		}
+
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+
+		return ids, nil
+	})
+}
+
+// ForSnippet returns all comments on the given snippet, oldest first.
+func (m *CommentModel) ForSnippet(snippetID int) ([]*Comment, error) {
+	defer instrument(m.OnQuery, "comments.ForSnippet", time.Now(), snippetID)
+
+	stmt := `SELECT c.id, c.snippet_id, c.user_id, u.name, c.body, c.created
+	FROM comments AS c INNER JOIN users AS u ON c.user_id = u.id
+	WHERE c.snippet_id = ? ORDER BY c.id ASC`
+
+	return readQuery(m.DB, m.ReadDB, func(db *sql.DB) ([]*Comment, error) {
+		rows, err := db.Query(stmt, snippetID)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		comments := []*Comment{}
+
+		for rows.Next() {
+			c := &Comment{}
+
+			err = rows.Scan(&c.ID, &c.SnippetID, &c.UserID, &c.Author, &c.Body, &c.Created)
+			if err != nil {
+				return nil, err
+			}
+
+			comments = append(comments, c)
+		}
+
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+
+		return comments, nil
+	})
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+
+	"snippetbox.ayubasayyed.net/internal/models/factory"
+)
+
+func TestCommentModelForSnippet(t *testing.T) {
+	db := newTestDB(t)
+	m := &CommentModel{DB: db}
+
+	alice := factory.User(t, db, factory.WithName("Alice"))
+	bob := factory.User(t, db, factory.WithName("Bob"))
+	snippetID := factory.Snippet(t, db)
+	other := factory.Snippet(t, db)
+
+	factory.Comment(t, db, snippetID, alice, factory.WithBody("First!"))
+	factory.Comment(t, db, snippetID, bob, factory.WithBody("Second."))
+	factory.Comment(t, db, other, alice)
+
+	comments, err := m.ForSnippet(snippetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(comments) != 2 {
+		t.Fatalf("ForSnippet() returned %d comments; want %d", len(comments), 2)
+	}
+	if comments[0].Author != "Alice" {
+		t.Errorf("first comment author = %q; want %q", comments[0].Author, "Alice")
+	}
+	if comments[1].Body != "Second." {
+		t.Errorf("second comment body = %q; want %q", comments[1].Body, "Second.")
+	}
+}
+
+func TestCommentModelCommenters(t *testing.T) {
+	db := newTestDB(t)
+	m := &CommentModel{DB: db}
+
+	alice := factory.User(t, db)
+	snippetID := factory.Snippet(t, db)
+
+	factory.Comment(t, db, snippetID, alice)
+	factory.Comment(t, db, snippetID, alice)
+
+	commenters, err := m.Commenters(snippetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(commenters) != 1 || commenters[0] != alice {
+		t.Errorf("Commenters() = %v; want [%d]", commenters, alice)
+	}
+}
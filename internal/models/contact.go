@@ -0,0 +1,79 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ContactMessage represents one message submitted through the contact
+// form.
+type ContactMessage struct {
+	ID      int
+	Name    string
+	Email   string
+	Message string
+	Created time.Time
+}
+
+// ContactModel wraps a sql.DB connection pool and provides methods for
+// working with the contact_messages table.
+type ContactModel struct {
+	DB *sql.DB
+
+	// OnQuery, if set, is called with the name and duration of every
+	// query this model runs.
+	OnQuery QueryHook
+}
+
+// Insert stores a new contact message and returns its ID.
+func (m *ContactModel) Insert(name, email, message string) (int, error) {
+	defer instrument(m.OnQuery, "contact.Insert", time.Now(), email)
+
+	stmt := `INSERT INTO contact_messages (name, email, message, created)
+	VALUES(?, ?, ?, ?)`
+
+	result, err := m.DB.Exec(stmt, name, email, message, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Recent returns up to limit contact messages, newest first.
+func (m *ContactModel) Recent(limit int) ([]*ContactMessage, error) {
+	defer instrument(m.OnQuery, "contact.Recent", time.Now(), limit)
+
+	stmt := `SELECT id, name, email, message, created FROM contact_messages
+	ORDER BY id DESC LIMIT ?`
+
+	rows, err := m.DB.Query(stmt, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*ContactMessage{}
+
+	for rows.Next() {
+		msg := &ContactMessage{}
+
+		err = rows.Scan(&msg.ID, &msg.Name, &msg.Email, &msg.Message, &msg.Created)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, msg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// QueryHook is called after each database query with the statement name,
+// how long it took and the query arguments, allowing callers to attach
+// instrumentation (metrics, slow query logging) to the models.
+type QueryHook func(name string, d time.Duration, args []any)
+
+// instrument invokes hook (if set) with the elapsed time since start. It is
+// intended to be deferred at the top of a model method:
+//
+//	defer instrument(m.OnQuery, "snippets.Get", time.Now(), id)
+func instrument(hook QueryHook, name string, start time.Time, args ...any) {
+	if hook != nil {
+		hook(name, time.Since(start), args)
+	}
+}
+
+// readQuery runs fn against the read replica when one is configured,
+// retrying once against the primary if the replica attempt fails with
+// anything other than a not-found result — sql.ErrNoRows, or the
+// ErrNoRecord the model closures map it to, is a successful round trip.
+// A dead replica therefore degrades to primary-only reads rather than an
+// outage.
+func readQuery[T any](primary, replica *sql.DB, fn func(db *sql.DB) (T, error)) (T, error) {
+	if replica == nil {
+		return fn(primary)
+	}
+
+	v, err := fn(replica)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) && !errors.Is(err, ErrNoRecord) {
+		return fn(primary)
+	}
+
+	return v, err
+}
+
+// RedactArgs renders query arguments as their types only, so slow query
+// log entries show the shape of a call without leaking user content or
+// credentials.
+func RedactArgs(args []any) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = fmt.Sprintf("<%T>", arg)
+	}
+	return redacted
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"snippetbox.ayubasayyed.net/internal/models/factory"
+)
+
+func TestReadQuery(t *testing.T) {
+	primary, replica := new(sql.DB), new(sql.DB)
+
+	tests := []struct {
+		name             string
+		replicaErr       error
+		wantErr          error
+		wantPrimaryCalls int
+	}{
+		{
+			name:             "Replica success",
+			replicaErr:       nil,
+			wantErr:          nil,
+			wantPrimaryCalls: 0,
+		},
+		{
+			name:             "Replica miss with ErrNoRecord",
+			replicaErr:       ErrNoRecord,
+			wantErr:          ErrNoRecord,
+			wantPrimaryCalls: 0,
+		},
+		{
+			name:             "Replica miss with sql.ErrNoRows",
+			replicaErr:       sql.ErrNoRows,
+			wantErr:          sql.ErrNoRows,
+			wantPrimaryCalls: 0,
+		},
+		{
+			name:             "Replica failure falls back",
+			replicaErr:       errors.New("replica down"),
+			wantErr:          nil,
+			wantPrimaryCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primaryCalls := 0
+
+			_, err := readQuery(primary, replica, func(db *sql.DB) (int, error) {
+				if db == primary {
+					primaryCalls++
+					return 1, nil
+				}
+				return 0, tt.replicaErr
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v; want %v", err, tt.wantErr)
+			}
+			if primaryCalls != tt.wantPrimaryCalls {
+				t.Errorf("primary queried %d times; want %d", primaryCalls, tt.wantPrimaryCalls)
+			}
+		})
+	}
+
+	t.Run("No replica", func(t *testing.T) {
+		primaryCalls := 0
+
+		_, err := readQuery[int](primary, nil, func(db *sql.DB) (int, error) {
+			primaryCalls++
+			return 1, nil
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		if primaryCalls != 1 {
+			t.Errorf("primary queried %d times; want %d", primaryCalls, 1)
+		}
+	})
+}
+
+// TestReadQueryReplicaMiss pins the routing end to end: a row present
+// only on the primary must not be served (or even looked for) when the
+// replica reports it missing.
+func TestReadQueryReplicaMiss(t *testing.T) {
+	primary := newTestDB(t)
+	replica := newTestDB(t)
+
+	m := &SnippetModel{DB: primary, ReadDB: replica}
+
+	id := factory.Snippet(t, primary)
+
+	if _, err := m.Get(id); !errors.Is(err, ErrNoRecord) {
+		t.Errorf("Get() with a replica miss returned %v; want ErrNoRecord", err)
+	}
+}
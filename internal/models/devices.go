@@ -0,0 +1,67 @@
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+)
+
+// LoginDeviceModel wraps a sql.DB connection pool and provides methods
+// for working with the login_devices table, which records the IP and
+// user-agent combinations each account has logged in from.
+type LoginDeviceModel struct {
+	DB *sql.DB
+
+	// OnQuery, if set, is called with the name and duration of every
+	// query this model runs.
+	OnQuery QueryHook
+}
+
+// Record stores a successful login's IP and user-agent combination.
+// Known reports whether the combination had been seen before for the
+// account, and first whether the account had no recorded combinations
+// at all until now. The user agent is matched by hash, since user-agent
+// strings are too long to index directly.
+func (m *LoginDeviceModel) Record(userID int, ip, userAgent string) (known, first bool, err error) {
+	defer instrument(m.OnQuery, "login_devices.Record", time.Now(), userID, ip)
+
+	sum := sha256.Sum256([]byte(userAgent))
+	uaHash := hex.EncodeToString(sum[:])
+
+	now := time.Now().UTC()
+
+	result, err := m.DB.Exec(`UPDATE login_devices SET last_seen = ?
+	WHERE user_id = ? AND ip = ? AND ua_hash = ?`, now, userID, ip, uaHash)
+	if err != nil {
+		return false, false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, false, err
+	}
+	if affected > 0 {
+		return true, false, nil
+	}
+
+	var count int
+	err = m.DB.QueryRow("SELECT COUNT(*) FROM login_devices WHERE user_id = ?", userID).Scan(&count)
+	if err != nil {
+		return false, false, err
+	}
+
+	// The stored string is for display on alert emails only; the hash
+	// above does the matching, so truncation loses nothing.
+	if len(userAgent) > 256 {
+		userAgent = userAgent[:256]
+	}
+
+	_, err = m.DB.Exec(`INSERT INTO login_devices (user_id, ip, ua_hash, user_agent, first_seen, last_seen)
+	VALUES(?, ?, ?, ?, ?, ?)`, userID, ip, uaHash, userAgent, now, now)
+	if err != nil {
+		return false, false, err
+	}
+
+	return false, count == 0, nil
+}
@@ -0,0 +1,63 @@
+package mocks
+
+import (
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/models"
+)
+
+// Comment is the canned comment served by CommentStore, attached to the
+// canned snippet and written by the canned user.
+var Comment = &models.Comment{
+	ID:        1,
+	SnippetID: 1,
+	UserID:    1,
+	Author:    "Alice",
+	Body:      "Lovely snippet.",
+	Created:   time.Now(),
+}
+
+// CommentStore is a mock models.CommentStore holding the one canned
+// comment with ID 1.
+type CommentStore struct {
+	// Err, when set, is returned by every method in place of its normal
+	// result.
+	Err error
+}
+
+func (s *CommentStore) Insert(snippetID, userID int, body string) (int, error) {
+	if s.Err != nil {
+		return 0, s.Err
+	}
+	return 2, nil
+}
+
+func (s *CommentStore) Get(id int) (*models.Comment, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	if id != Comment.ID {
+		return nil, models.ErrNoRecord
+	}
+	return Comment, nil
+}
+
+func (s *CommentStore) ForSnippet(snippetID int) ([]*models.Comment, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	if snippetID != Comment.SnippetID {
+		return nil, nil
+	}
+	return []*models.Comment{Comment}, nil
+}
+
+func (s *CommentStore) Commenters(snippetID int) ([]int, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	if snippetID != Comment.SnippetID {
+		return nil, nil
+	}
+	return []int{Comment.UserID}, nil
+}
@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/models"
+)
+
+// ContactMessage is the canned message served by ContactStore.
+var ContactMessage = &models.ContactMessage{
+	ID:      1,
+	Name:    "Alice",
+	Email:   "alice@example.com",
+	Message: "Hello from the contact form.",
+	Created: time.Now(),
+}
+
+// ContactStore is a mock models.ContactStore holding the one canned
+// message with ID 1.
+type ContactStore struct {
+	// Err, when set, is returned by every method in place of its normal
+	// result.
+	Err error
+}
+
+func (s *ContactStore) Insert(name, email, message string) (int, error) {
+	if s.Err != nil {
+		return 0, s.Err
+	}
+	return 2, nil
+}
+
+func (s *ContactStore) Recent(limit int) ([]*models.ContactMessage, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return []*models.ContactMessage{ContactMessage}, nil
+}
@@ -0,0 +1,16 @@
+package mocks
+
+// LoginDeviceStore is a mock models.LoginDeviceStore that reports every
+// device as already known, so logins in tests never trigger alerts.
+type LoginDeviceStore struct {
+	// Err, when set, is returned by Record in place of its normal
+	// result.
+	Err error
+}
+
+func (s *LoginDeviceStore) Record(userID int, ip, userAgent string) (known, first bool, err error) {
+	if s.Err != nil {
+		return false, false, s.Err
+	}
+	return true, false, nil
+}
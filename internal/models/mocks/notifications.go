@@ -0,0 +1,53 @@
+package mocks
+
+import "snippetbox.ayubasayyed.net/internal/models"
+
+// NotificationStore is a mock models.NotificationStore with no stored
+// notifications and no stored preferences, so every event falls back to
+// its default.
+type NotificationStore struct {
+	// Err, when set, is returned by every method in place of its normal
+	// result.
+	Err error
+}
+
+func (s *NotificationStore) Insert(userID int, kind, actor string, snippetID int) (int, error) {
+	if s.Err != nil {
+		return 0, s.Err
+	}
+	return 1, nil
+}
+
+func (s *NotificationStore) ForUser(userID, limit int) ([]*models.Notification, error) {
+	return nil, s.Err
+}
+
+func (s *NotificationStore) UnreadCount(userID int) (int, error) {
+	if s.Err != nil {
+		return 0, s.Err
+	}
+	return 0, nil
+}
+
+func (s *NotificationStore) MarkRead(id, userID int) error {
+	return s.Err
+}
+
+func (s *NotificationStore) MarkAllRead(userID int) error {
+	return s.Err
+}
+
+func (s *NotificationStore) Prefs(userID int) (map[string]models.NotificationPref, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return map[string]models.NotificationPref{}, nil
+}
+
+func (s *NotificationStore) SetPref(userID int, event string, pref models.NotificationPref) error {
+	return s.Err
+}
+
+func (s *NotificationStore) EmailSubscribers(event string) ([]*models.User, error) {
+	return nil, s.Err
+}
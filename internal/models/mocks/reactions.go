@@ -0,0 +1,22 @@
+package mocks
+
+import "snippetbox.ayubasayyed.net/internal/models"
+
+// ReactionStore is a mock models.ReactionStore with no stored reactions.
+// Toggle always reports the reaction as newly added.
+type ReactionStore struct {
+	// Err, when set, is returned by every method in place of its normal
+	// result.
+	Err error
+}
+
+func (s *ReactionStore) Toggle(snippetID, userID int, emoji string) (bool, error) {
+	if s.Err != nil {
+		return false, s.Err
+	}
+	return true, nil
+}
+
+func (s *ReactionStore) Counts(snippetID, userID int) ([]models.ReactionCount, error) {
+	return nil, s.Err
+}
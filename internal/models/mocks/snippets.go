@@ -0,0 +1,85 @@
+// Package mocks provides in-memory implementations of the model store
+// interfaces for handler unit tests that shouldn't need a real database.
+// Each store serves a small amount of canned data and has an Err field
+// for injecting database failures.
+package mocks
+
+import (
+	"strings"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/models"
+)
+
+// Snippet is the canned snippet served by SnippetStore.
+var Snippet = &models.Snippet{
+	ID:      1,
+	Title:   "An old silent pond",
+	Content: "An old silent pond...\nA frog jumps into the pond,\nsplash! Silence again.",
+	Created: time.Now(),
+	Expires: time.Now().Add(365 * 24 * time.Hour),
+	Copies:  3,
+}
+
+// SnippetStore is a mock models.SnippetStore holding the one canned
+// snippet with ID 1.
+type SnippetStore struct {
+	// Err, when set, is returned by every method in place of its normal
+	// result.
+	Err error
+}
+
+func (s *SnippetStore) Insert(title string, content string, expires int) (int, error) {
+	if s.Err != nil {
+		return 0, s.Err
+	}
+	return 2, nil
+}
+
+func (s *SnippetStore) Get(id int) (*models.Snippet, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	if id != Snippet.ID {
+		return nil, models.ErrNoRecord
+	}
+	return Snippet, nil
+}
+
+func (s *SnippetStore) Count() (int, error) {
+	if s.Err != nil {
+		return 0, s.Err
+	}
+	return 1, nil
+}
+
+func (s *SnippetStore) List(limit, offset int) ([]*models.Snippet, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	if offset > 0 {
+		return nil, nil
+	}
+	return []*models.Snippet{Snippet}, nil
+}
+
+func (s *SnippetStore) Popular(since time.Time, limit int) ([]*models.Snippet, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	return []*models.Snippet{Snippet}, nil
+}
+
+func (s *SnippetStore) TitleSuggestions(prefix string, limit int) ([]string, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	if strings.HasPrefix(strings.ToLower(Snippet.Title), strings.ToLower(prefix)) {
+		return []string{Snippet.Title}, nil
+	}
+	return nil, nil
+}
+
+func (s *SnippetStore) AddCopies(counts map[int]int) error {
+	return s.Err
+}
@@ -0,0 +1,81 @@
+package mocks
+
+import (
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/models"
+)
+
+// User is the canned user served by UserStore. Authenticate accepts its
+// email with the password "pa$$word".
+var User = &models.User{
+	ID:      1,
+	Name:    "Alice",
+	Email:   "alice@example.com",
+	Created: time.Now(),
+}
+
+// UserStore is a mock models.UserStore holding the one canned user with
+// ID 1. Insert treats "dupe@example.com" as already taken.
+type UserStore struct {
+	// Err, when set, is returned by every method in place of its normal
+	// result.
+	Err error
+}
+
+func (s *UserStore) Insert(name, email, password string) error {
+	if s.Err != nil {
+		return s.Err
+	}
+	if email == "dupe@example.com" {
+		return models.ErrDuplicateEmail
+	}
+	return nil
+}
+
+func (s *UserStore) Authenticate(email, password string) (int, error) {
+	if s.Err != nil {
+		return 0, s.Err
+	}
+	if email == User.Email && password == "pa$$word" {
+		return User.ID, nil
+	}
+	return 0, models.ErrInvalidCredentials
+}
+
+func (s *UserStore) Get(id int) (*models.User, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	if id != User.ID {
+		return nil, models.ErrNoRecord
+	}
+	return User, nil
+}
+
+func (s *UserStore) ByNames(names []string) (map[string]int, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	ids := map[string]int{}
+	for _, name := range names {
+		if name == User.Name {
+			ids[name] = User.ID
+		}
+	}
+	return ids, nil
+}
+
+func (s *UserStore) Exists(id int) (bool, error) {
+	if s.Err != nil {
+		return false, s.Err
+	}
+	return id == User.ID, nil
+}
+
+func (s *UserStore) IsAdmin(id int) (bool, error) {
+	if s.Err != nil {
+		return false, s.Err
+	}
+	return id == User.ID && User.Admin, nil
+}
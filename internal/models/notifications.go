@@ -0,0 +1,249 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Notification kinds. The kind selects the message shown to the user;
+// actor and snippet ID fill in the details.
+const (
+	NotificationComment  = "comment"
+	NotificationMention  = "mention"
+	NotificationExpiry   = "expiry"
+	NotificationSecurity = "security"
+	NotificationDigest   = "digest"
+)
+
+// NotificationEvents lists every notification kind a user can hold
+// preferences for, in display order.
+var NotificationEvents = []string{
+	NotificationComment,
+	NotificationMention,
+	NotificationExpiry,
+	NotificationSecurity,
+	NotificationDigest,
+}
+
+// Notification represents a single event relevant to a user, such as a
+// new comment on a snippet they took part in.
+type Notification struct {
+	ID        int
+	UserID    int
+	Kind      string
+	Actor     string
+	SnippetID int
+	Created   time.Time
+	Read      bool
+}
+
+// NotificationModel wraps a sql.DB connection pool and provides methods
+// for working with the notifications table.
+type NotificationModel struct {
+	DB *sql.DB
+
+	// ReadDB, if set, is a read-replica pool that ForUser and UnreadCount
+	// prefer over DB, falling back to DB when the replica is unavailable.
+	ReadDB *sql.DB
+
+	// OnQuery, if set, is called with the name and duration of every
+	// query this model runs.
+	OnQuery QueryHook
+}
+
+// Insert records a new unread notification for the given user and returns
+// its ID.
+func (m *NotificationModel) Insert(userID int, kind, actor string, snippetID int) (int, error) {
+	defer instrument(m.OnQuery, "notifications.Insert", time.Now(), userID, kind)
+
+	stmt := `INSERT INTO notifications (user_id, kind, actor, snippet_id, created)
+	VALUES(?, ?, ?, ?, ?)`
+
+	result, err := m.DB.Exec(stmt, userID, kind, actor, snippetID, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// ForUser returns the given user's most recent notifications, newest
+// first, up to limit.
+func (m *NotificationModel) ForUser(userID, limit int) ([]*Notification, error) {
+	defer instrument(m.OnQuery, "notifications.ForUser", time.Now(), userID, limit)
+
+	stmt := `SELECT id, user_id, kind, actor, snippet_id, created, read_at
+	FROM notifications WHERE user_id = ? ORDER BY id DESC LIMIT ?`
+
+	return readQuery(m.DB, m.ReadDB, func(db *sql.DB) ([]*Notification, error) {
+		rows, err := db.Query(stmt, userID, limit)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		notifications := []*Notification{}
+
+		for rows.Next() {
+			n := &Notification{}
+			var readAt sql.NullTime
+
+			err = rows.Scan(&n.ID, &n.UserID, &n.Kind, &n.Actor, &n.SnippetID, &n.Created, &readAt)
+			if err != nil {
+				return nil, err
+			}
+
+			n.Read = readAt.Valid
+			notifications = append(notifications, n)
+		}
+
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+
+		return notifications, nil
+	})
+}
+
+// UnreadCount returns how many of the given user's notifications are
+// still unread.
+func (m *NotificationModel) UnreadCount(userID int) (int, error) {
+	defer instrument(m.OnQuery, "notifications.UnreadCount", time.Now(), userID)
+
+	stmt := `SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`
+
+	return readQuery(m.DB, m.ReadDB, func(db *sql.DB) (int, error) {
+		var count int
+
+		err := db.QueryRow(stmt, userID).Scan(&count)
+		if err != nil {
+			return 0, err
+		}
+
+		return count, nil
+	})
+}
+
+// MarkRead marks a single notification as read. The user ID guards
+// against marking another user's notification; a non-match is a no-op.
+func (m *NotificationModel) MarkRead(id, userID int) error {
+	defer instrument(m.OnQuery, "notifications.MarkRead", time.Now(), id, userID)
+
+	stmt := `UPDATE notifications SET read_at = ?
+	WHERE id = ? AND user_id = ? AND read_at IS NULL`
+
+	_, err := m.DB.Exec(stmt, time.Now().UTC(), id, userID)
+	return err
+}
+
+// NotificationPref holds a user's delivery choices for one event kind.
+// Events without a stored row default to both channels enabled.
+type NotificationPref struct {
+	InApp bool
+	Email bool
+}
+
+// Prefs returns the given user's notification preferences keyed by event.
+// Events the user has never changed are absent from the map.
+func (m *NotificationModel) Prefs(userID int) (map[string]NotificationPref, error) {
+	defer instrument(m.OnQuery, "notifications.Prefs", time.Now(), userID)
+
+	stmt := `SELECT event, in_app, email FROM notification_prefs WHERE user_id = ?`
+
+	return readQuery(m.DB, m.ReadDB, func(db *sql.DB) (map[string]NotificationPref, error) {
+		rows, err := db.Query(stmt, userID)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		prefs := map[string]NotificationPref{}
+
+		for rows.Next() {
+			var event string
+			var p NotificationPref
+
+			if err = rows.Scan(&event, &p.InApp, &p.Email); err != nil {
+				return nil, err
+			}
+
+			prefs[event] = p
+		}
+
+		return prefs, rows.Err()
+	})
+}
+
+// EmailSubscribers returns the users who have opted in to email for the
+// given event. Only users with an explicit preference row count — email
+// events are opt-in, unlike the in-app channel's on-by-default.
+func (m *NotificationModel) EmailSubscribers(event string) ([]*User, error) {
+	defer instrument(m.OnQuery, "notifications.EmailSubscribers", time.Now(), event)
+
+	stmt := `SELECT u.id, u.name, u.email FROM users u
+	JOIN notification_prefs p ON p.user_id = u.id
+	WHERE p.event = ? AND p.email = TRUE ORDER BY u.id`
+
+	return readQuery(m.DB, m.ReadDB, func(db *sql.DB) ([]*User, error) {
+		rows, err := db.Query(stmt, event)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		var users []*User
+
+		for rows.Next() {
+			u := &User{}
+
+			if err = rows.Scan(&u.ID, &u.Name, &u.Email); err != nil {
+				return nil, err
+			}
+
+			users = append(users, u)
+		}
+
+		return users, rows.Err()
+	})
+}
+
+// SetPref inserts or replaces the user's preference for one event.
+func (m *NotificationModel) SetPref(userID int, event string, pref NotificationPref) error {
+	defer instrument(m.OnQuery, "notifications.SetPref", time.Now(), userID, event)
+
+	stmt := `UPDATE notification_prefs SET in_app = ?, email = ?
+	WHERE user_id = ? AND event = ?`
+
+	result, err := m.DB.Exec(stmt, pref.InApp, pref.Email, userID, event)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		stmt = `INSERT INTO notification_prefs (user_id, event, in_app, email)
+		VALUES(?, ?, ?, ?)`
+		_, err = m.DB.Exec(stmt, userID, event, pref.InApp, pref.Email)
+	}
+
+	return err
+}
+
+// MarkAllRead marks all of the given user's notifications as read.
+func (m *NotificationModel) MarkAllRead(userID int) error {
+	defer instrument(m.OnQuery, "notifications.MarkAllRead", time.Now(), userID)
+
+	stmt := `UPDATE notifications SET read_at = ?
+	WHERE user_id = ? AND read_at IS NULL`
+
+	_, err := m.DB.Exec(stmt, time.Now().UTC(), userID)
+	return err
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ReactionCount aggregates one emoji's reactions on a snippet, along
+// with whether the viewing user is among them.
+type ReactionCount struct {
+	Emoji string
+	Count int
+	Mine  bool
+}
+
+// ReactionModel wraps a sql.DB connection pool and provides methods for
+// working with the reactions table.
+type ReactionModel struct {
+	DB *sql.DB
+
+	// ReadDB, if set, is a read-replica pool that Counts prefers over DB,
+	// falling back to DB when the replica is unavailable.
+	ReadDB *sql.DB
+
+	// OnQuery, if set, is called with the name and duration of every
+	// query this model runs.
+	OnQuery QueryHook
+}
+
+// Toggle adds the user's reaction if absent and removes it if present,
+// returning whether the reaction exists afterwards.
+func (m *ReactionModel) Toggle(snippetID, userID int, emoji string) (bool, error) {
+	defer instrument(m.OnQuery, "reactions.Toggle", time.Now(), snippetID, userID)
+
+	stmt := `DELETE FROM reactions
+	WHERE snippet_id = ? AND user_id = ? AND emoji = ?`
+
+	result, err := m.DB.Exec(stmt, snippetID, userID, emoji)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected > 0 {
+		return false, nil
+	}
+
+	stmt = `INSERT INTO reactions (snippet_id, user_id, emoji, created)
+	VALUES(?, ?, ?, ?)`
+
+	_, err = m.DB.Exec(stmt, snippetID, userID, emoji, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Counts returns the per-emoji reaction totals for a snippet, marking
+// the ones the given user has made. A user ID of zero means anonymous.
+func (m *ReactionModel) Counts(snippetID, userID int) ([]ReactionCount, error) {
+	defer instrument(m.OnQuery, "reactions.Counts", time.Now(), snippetID)
+
+	stmt := `SELECT emoji, COUNT(*), SUM(CASE WHEN user_id = ? THEN 1 ELSE 0 END)
+	FROM reactions WHERE snippet_id = ? GROUP BY emoji`
+
+	return readQuery(m.DB, m.ReadDB, func(db *sql.DB) ([]ReactionCount, error) {
+		rows, err := db.Query(stmt, userID, snippetID)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		counts := []ReactionCount{}
+
+		for rows.Next() {
+			var rc ReactionCount
+			var mine int
+
+			if err = rows.Scan(&rc.Emoji, &rc.Count, &mine); err != nil {
+				return nil, err
+			}
+
+			rc.Mine = mine > 0
+			counts = append(counts, rc)
+		}
+
+		return counts, rows.Err()
+	})
+}
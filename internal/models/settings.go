@@ -0,0 +1,79 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// SettingModel wraps a sql.DB connection pool and provides methods for
+// working with the settings table, a simple name/value store for
+// admin-tunable site configuration.
+type SettingModel struct {
+	DB *sql.DB
+
+	// OnQuery, if set, is called with the name and duration of every
+	// query this model runs.
+	OnQuery QueryHook
+}
+
+// All returns every stored setting keyed by name.
+func (m *SettingModel) All() (map[string]string, error) {
+	defer instrument(m.OnQuery, "settings.All", time.Now())
+
+	rows, err := m.DB.Query("SELECT name, value FROM settings")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := map[string]string{}
+
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		settings[name] = value
+	}
+
+	return settings, rows.Err()
+}
+
+// Get returns the named setting's value, with ok reporting whether it
+// is stored.
+func (m *SettingModel) Get(name string) (value string, ok bool, err error) {
+	defer instrument(m.OnQuery, "settings.Get", time.Now(), name)
+
+	err = m.DB.QueryRow("SELECT value FROM settings WHERE name = ?", name).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return value, true, nil
+}
+
+// Set inserts or replaces the named setting.
+func (m *SettingModel) Set(name, value string) error {
+	defer instrument(m.OnQuery, "settings.Set", time.Now(), name)
+
+	now := time.Now().UTC()
+
+	result, err := m.DB.Exec("UPDATE settings SET value = ?, updated = ? WHERE name = ?", value, now, name)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		_, err = m.DB.Exec("INSERT INTO settings (name, value, updated) VALUES(?, ?, ?)", name, value, now)
+	}
+
+	return err
+}
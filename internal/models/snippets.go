@@ -0,0 +1,239 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Snippet represents an individual snippet record.
+type Snippet struct {
+	ID      int
+	Title   string
+	Content string
+	Created time.Time
+	Expires time.Time
+	Copies  int
+}
+
+// SnippetModel wraps a sql.DB connection pool and provides methods for
+// working with the snippets table.
+type SnippetModel struct {
+	DB *sql.DB
+
+	// ReadDB, if set, is a read-replica pool that the read methods prefer
+	// over DB, falling back to DB when the replica is unavailable.
+	ReadDB *sql.DB
+
+	// OnQuery, if set, is called with the name and duration of every
+	// query this model runs.
+	OnQuery QueryHook
+}
+
+// Insert adds a new snippet to the database and returns its ID.
+func (m *SnippetModel) Insert(title string, content string, expires int) (int, error) {
+	defer instrument(m.OnQuery, "snippets.Insert", time.Now(), title, expires)
+
+	stmt := `INSERT INTO snippets (title, content, created, expires)
+	VALUES(?, ?, ?, ?)`
+
+	now := time.Now().UTC()
+
+	result, err := m.DB.Exec(stmt, title, content, now, now.AddDate(0, 0, expires))
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Purge hard-deletes snippets that expired before the given time and
+// returns how many rows were removed.
+func (m *SnippetModel) Purge(before time.Time) (int64, error) {
+	defer instrument(m.OnQuery, "snippets.Purge", time.Now(), before)
+
+	result, err := m.DB.Exec(`DELETE FROM snippets WHERE expires < ?`, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Get returns the snippet with the given ID, provided it hasn't expired.
+func (m *SnippetModel) Get(id int) (*Snippet, error) {
+	defer instrument(m.OnQuery, "snippets.Get", time.Now(), id)
+
+	stmt := `SELECT id, title, content, created, expires, copies FROM snippets
+	WHERE expires > ? AND id = ?`
+
+	return readQuery(m.DB, m.ReadDB, func(db *sql.DB) (*Snippet, error) {
+		row := db.QueryRow(stmt, time.Now().UTC(), id)
+
+		s := &Snippet{}
+
+		err := row.Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires, &s.Copies)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrNoRecord
+			}
+			return nil, err
+		}
+
+		return s, nil
+	})
+}
+
+// Count returns how many snippets haven't expired.
+func (m *SnippetModel) Count() (int, error) {
+	defer instrument(m.OnQuery, "snippets.Count", time.Now())
+
+	stmt := `SELECT COUNT(*) FROM snippets WHERE expires > ?`
+
+	return readQuery(m.DB, m.ReadDB, func(db *sql.DB) (int, error) {
+		var n int
+		err := db.QueryRow(stmt, time.Now().UTC()).Scan(&n)
+		return n, err
+	})
+}
+
+// AddCopies adds the batched copy counts to their snippets in one
+// transaction. Counts for snippets that have since been deleted simply
+// update no rows.
+func (m *SnippetModel) AddCopies(counts map[int]int) error {
+	defer instrument(m.OnQuery, "snippets.AddCopies", time.Now(), len(counts))
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt := `UPDATE snippets SET copies = copies + ? WHERE id = ?`
+
+	for id, n := range counts {
+		if _, err := tx.Exec(stmt, n, id); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// likeEscaper neutralises LIKE wildcards in user input so they match
+// literally. The escape character is '!' rather than backslash, which
+// MySQL would otherwise treat as a string escape too.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// TitleSuggestions returns up to limit distinct titles of unexpired
+// snippets starting with prefix, in alphabetical order.
+func (m *SnippetModel) TitleSuggestions(prefix string, limit int) ([]string, error) {
+	defer instrument(m.OnQuery, "snippets.TitleSuggestions", time.Now(), prefix, limit)
+
+	stmt := `SELECT DISTINCT title FROM snippets
+	WHERE expires > ? AND title LIKE ? ESCAPE '!' ORDER BY title LIMIT ?`
+
+	pattern := likeEscaper.Replace(prefix) + "%"
+
+	return readQuery(m.DB, m.ReadDB, func(db *sql.DB) ([]string, error) {
+		rows, err := db.Query(stmt, time.Now().UTC(), pattern, limit)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		titles := []string{}
+
+		for rows.Next() {
+			var title string
+			if err := rows.Scan(&title); err != nil {
+				return nil, err
+			}
+			titles = append(titles, title)
+		}
+
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+
+		return titles, nil
+	})
+}
+
+// Popular returns up to limit unexpired snippets created since the
+// given time, most copied first, for the weekly digest.
+func (m *SnippetModel) Popular(since time.Time, limit int) ([]*Snippet, error) {
+	defer instrument(m.OnQuery, "snippets.Popular", time.Now(), since, limit)
+
+	stmt := `SELECT id, title, content, created, expires, copies FROM snippets
+	WHERE created >= ? AND expires > ? ORDER BY copies DESC, id DESC LIMIT ?`
+
+	return readQuery(m.DB, m.ReadDB, func(db *sql.DB) ([]*Snippet, error) {
+		rows, err := db.Query(stmt, since, time.Now().UTC(), limit)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		snippets := []*Snippet{}
+
+		for rows.Next() {
+			s := &Snippet{}
+
+			err = rows.Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires, &s.Copies)
+			if err != nil {
+				return nil, err
+			}
+
+			snippets = append(snippets, s)
+		}
+
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+
+		return snippets, nil
+	})
+}
+
+// List returns up to limit unexpired snippets, newest first, skipping
+// the first offset rows.
+func (m *SnippetModel) List(limit, offset int) ([]*Snippet, error) {
+	defer instrument(m.OnQuery, "snippets.List", time.Now(), limit, offset)
+
+	stmt := `SELECT id, title, content, created, expires, copies FROM snippets
+	WHERE expires > ? ORDER BY id DESC LIMIT ? OFFSET ?`
+
+	return readQuery(m.DB, m.ReadDB, func(db *sql.DB) ([]*Snippet, error) {
+		rows, err := db.Query(stmt, time.Now().UTC(), limit, offset)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		snippets := []*Snippet{}
+
+		for rows.Next() {
+			s := &Snippet{}
+
+			err = rows.Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires, &s.Copies)
+			if err != nil {
+				return nil, err
+			}
+
+			snippets = append(snippets, s)
+		}
+
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+
+		return snippets, nil
+	})
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"snippetbox.ayubasayyed.net/internal/models/factory"
+)
+
+func TestSnippetModelInsertGet(t *testing.T) {
+	m := &SnippetModel{DB: newTestDB(t)}
+
+	id, err := m.Insert("An old silent pond", "An old silent pond...", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snippet, err := m.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if snippet.Title != "An old silent pond" {
+		t.Errorf("Title = %q; want %q", snippet.Title, "An old silent pond")
+	}
+	if snippet.Content != "An old silent pond..." {
+		t.Errorf("Content = %q; want %q", snippet.Content, "An old silent pond...")
+	}
+
+	_, err = m.Get(id + 1)
+	if !errors.Is(err, ErrNoRecord) {
+		t.Errorf("Get(%d) error = %v; want ErrNoRecord", id+1, err)
+	}
+}
+
+func TestSnippetModelList(t *testing.T) {
+	m := &SnippetModel{DB: newTestDB(t)}
+
+	for _, title := range []string{"First", "Second", "Third"} {
+		if _, err := m.Insert(title, "content", 7); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count, err := m.Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("Count() = %d; want %d", count, 3)
+	}
+
+	snippets, err := m.List(2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snippets) != 2 {
+		t.Fatalf("List(2, 0) returned %d snippets; want %d", len(snippets), 2)
+	}
+	if snippets[0].Title != "Third" {
+		t.Errorf("first listed title = %q; want %q (newest first)", snippets[0].Title, "Third")
+	}
+}
+
+func TestSnippetModelGetExpired(t *testing.T) {
+	db := newTestDB(t)
+	m := &SnippetModel{DB: db}
+
+	id := factory.Snippet(t, db, factory.WithExpiry(-1))
+
+	_, err := m.Get(id)
+	if !errors.Is(err, ErrNoRecord) {
+		t.Errorf("Get() on an expired snippet returned %v; want ErrNoRecord", err)
+	}
+}
+
+func TestSnippetModelPopular(t *testing.T) {
+	db := newTestDB(t)
+	m := &SnippetModel{DB: db}
+
+	quiet := factory.Snippet(t, db, factory.WithCopies(1))
+	busy := factory.Snippet(t, db, factory.WithCopies(9))
+	factory.Snippet(t, db, factory.WithCopies(99), factory.WithCreated(time.Now().UTC().AddDate(0, 0, -14)))
+
+	snippets, err := m.Popular(time.Now().UTC().AddDate(0, 0, -7), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(snippets) != 2 {
+		t.Fatalf("Popular() returned %d snippets; want %d", len(snippets), 2)
+	}
+	if snippets[0].ID != busy || snippets[1].ID != quiet {
+		t.Errorf("Popular() order = [%d %d]; want [%d %d]", snippets[0].ID, snippets[1].ID, busy, quiet)
+	}
+}
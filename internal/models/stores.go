@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// SnippetStore describes the snippet storage operations the web application
+// depends on. *SnippetModel is the database-backed implementation;
+// alternative backends and test mocks implement the same interface.
+type SnippetStore interface {
+	Insert(title string, content string, expires int) (int, error)
+	Get(id int) (*Snippet, error)
+	Count() (int, error)
+	List(limit, offset int) ([]*Snippet, error)
+	Popular(since time.Time, limit int) ([]*Snippet, error)
+	TitleSuggestions(prefix string, limit int) ([]string, error)
+	AddCopies(counts map[int]int) error
+}
+
+// UserStore describes the user storage operations the web application
+// depends on.
+type UserStore interface {
+	Insert(name, email, password string) error
+	Authenticate(email, password string) (int, error)
+	Get(id int) (*User, error)
+	ByNames(names []string) (map[string]int, error)
+	Exists(id int) (bool, error)
+	IsAdmin(id int) (bool, error)
+}
+
+// CommentStore describes the comment storage operations the web
+// application depends on.
+type CommentStore interface {
+	Insert(snippetID, userID int, body string) (int, error)
+	Get(id int) (*Comment, error)
+	ForSnippet(snippetID int) ([]*Comment, error)
+	Commenters(snippetID int) ([]int, error)
+}
+
+// NotificationStore describes the notification storage operations the
+// web application depends on.
+type NotificationStore interface {
+	Insert(userID int, kind, actor string, snippetID int) (int, error)
+	ForUser(userID, limit int) ([]*Notification, error)
+	UnreadCount(userID int) (int, error)
+	MarkRead(id, userID int) error
+	MarkAllRead(userID int) error
+	Prefs(userID int) (map[string]NotificationPref, error)
+	SetPref(userID int, event string, pref NotificationPref) error
+	EmailSubscribers(event string) ([]*User, error)
+}
+
+// ReactionStore describes the reaction storage operations the web
+// application depends on.
+type ReactionStore interface {
+	Toggle(snippetID, userID int, emoji string) (bool, error)
+	Counts(snippetID, userID int) ([]ReactionCount, error)
+}
+
+// LoginDeviceStore describes the login device storage operations the
+// web application depends on.
+type LoginDeviceStore interface {
+	Record(userID int, ip, userAgent string) (known, first bool, err error)
+}
+
+// ContactStore describes the contact message storage operations the web
+// application depends on.
+type ContactStore interface {
+	Insert(name, email, message string) (int, error)
+	Recent(limit int) ([]*ContactMessage, error)
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"snippetbox.ayubasayyed.net/internal/migrations"
+)
+
+// newTestDB returns a freshly migrated database for model integration
+// tests. By default it uses a throwaway SQLite file, so the tests run
+// anywhere without setup. Set SNIPPETBOX_TEST_DSN to point them at a
+// dedicated MySQL test schema instead; its tables are emptied after each
+// test, and tests skip cleanly when that database can't be reached.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	driver, dsn := "sqlite3", filepath.Join(t.TempDir(), "test.db")
+	if v := os.Getenv("SNIPPETBOX_TEST_DSN"); v != "" {
+		driver, dsn = "mysql", v
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		if driver == "mysql" {
+			t.Skipf("test database unavailable: %v", err)
+		}
+		t.Fatal(err)
+	}
+
+	if _, err := migrations.Up(db, driver); err != nil {
+		db.Close()
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if driver == "mysql" {
+			truncateTables(t, db)
+		}
+		db.Close()
+	})
+
+	return db
+}
+
+// truncateTables empties every application table in the MySQL test
+// schema, so state from one test can't leak into the next. The
+// schema_migrations table is left alone: the schema itself is reused
+// across tests and runs.
+func truncateTables(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	rows, err := db.Query("SHOW TABLES")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			t.Fatal(err)
+		}
+		if table != "schema_migrations" {
+			tables = append(tables, table)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range tables {
+		if _, err := db.Exec("TRUNCATE TABLE " + table); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec("SET FOREIGN_KEY_CHECKS = 1"); err != nil {
+		t.Fatal(err)
+	}
+}
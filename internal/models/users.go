@@ -0,0 +1,207 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User represents an individual user record.
+type User struct {
+	ID             int
+	Name           string
+	Email          string
+	HashedPassword []byte
+	Created        time.Time
+	Admin          bool
+}
+
+// UserModel wraps a sql.DB connection pool and provides methods for working
+// with the users table.
+type UserModel struct {
+	DB *sql.DB
+
+	// OnQuery, if set, is called with the name and duration of every
+	// query this model runs.
+	OnQuery QueryHook
+}
+
+// Insert adds a new user record to the database, hashing the plain-text
+// password with bcrypt first.
+func (m *UserModel) Insert(name, email, password string) error {
+	defer instrument(m.OnQuery, "users.Insert", time.Now(), email)
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return err
+	}
+
+	stmt := `INSERT INTO users (name, email, hashed_password, created)
+	VALUES(?, ?, ?, ?)`
+
+	_, err = m.DB.Exec(stmt, name, email, string(hashedPassword), time.Now().UTC())
+	if err != nil {
+		var mySQLError *mysql.MySQLError
+		if errors.As(err, &mySQLError) {
+			if mySQLError.Number == 1062 && strings.Contains(mySQLError.Message, "users_uc_email") {
+				return ErrDuplicateEmail
+			}
+		}
+
+		var sqliteError sqlite3.Error
+		if errors.As(err, &sqliteError) {
+			if sqliteError.ExtendedCode == sqlite3.ErrConstraintUnique && strings.Contains(sqliteError.Error(), "users.email") {
+				return ErrDuplicateEmail
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Get returns the user with the given ID, without the password hash.
+func (m *UserModel) Get(id int) (*User, error) {
+	defer instrument(m.OnQuery, "users.Get", time.Now(), id)
+
+	stmt := `SELECT id, name, email, created, admin FROM users WHERE id = ?`
+
+	u := &User{}
+
+	err := m.DB.QueryRow(stmt, id).Scan(&u.ID, &u.Name, &u.Email, &u.Created, &u.Admin)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// ByNames resolves the given display names to user IDs, matching
+// case-insensitively. Names with no matching user are simply absent from
+// the result, which is keyed by lower-cased name.
+func (m *UserModel) ByNames(names []string) (map[string]int, error) {
+	defer instrument(m.OnQuery, "users.ByNames", time.Now(), len(names))
+
+	if len(names) == 0 {
+		return map[string]int{}, nil
+	}
+
+	args := make([]any, len(names))
+	for i, name := range names {
+		args[i] = strings.ToLower(name)
+	}
+
+	stmt := `SELECT id, name FROM users WHERE LOWER(name) IN (?` +
+		strings.Repeat(", ?", len(names)-1) + `)`
+
+	rows, err := m.DB.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := map[string]int{}
+
+	for rows.Next() {
+		var id int
+		var name string
+
+		if err = rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+
+		users[strings.ToLower(name)] = id
+	}
+
+	return users, rows.Err()
+}
+
+// Authenticate verifies whether a user exists with the provided email
+// address and password. It returns the user's ID on success.
+func (m *UserModel) Authenticate(email, password string) (int, error) {
+	defer instrument(m.OnQuery, "users.Authenticate", time.Now(), email)
+
+	var id int
+	var hashedPassword []byte
+
+	stmt := `SELECT id, hashed_password FROM users WHERE email = ?`
+
+	err := m.DB.QueryRow(stmt, email).Scan(&id, &hashedPassword)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInvalidCredentials
+		}
+		return 0, err
+	}
+
+	err = bcrypt.CompareHashAndPassword(hashedPassword, []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return 0, ErrInvalidCredentials
+		}
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// IsAdmin reports whether the user with the given ID is an administrator.
+func (m *UserModel) IsAdmin(id int) (bool, error) {
+	defer instrument(m.OnQuery, "users.IsAdmin", time.Now(), id)
+
+	var admin bool
+
+	stmt := `SELECT admin FROM users WHERE id = ?`
+
+	err := m.DB.QueryRow(stmt, id).Scan(&admin)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return admin, nil
+}
+
+// MakeAdmin grants admin rights to the user with the given email address,
+// returning ErrNoRecord if no such user exists.
+func (m *UserModel) MakeAdmin(email string) error {
+	defer instrument(m.OnQuery, "users.MakeAdmin", time.Now())
+
+	result, err := m.DB.Exec("UPDATE users SET admin = TRUE WHERE email = ?", email)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// Exists reports whether a user with the given ID exists.
+func (m *UserModel) Exists(id int) (bool, error) {
+	defer instrument(m.OnQuery, "users.Exists", time.Now(), id)
+
+	var exists bool
+
+	stmt := `SELECT EXISTS(SELECT true FROM users WHERE id = ?)`
+
+	err := m.DB.QueryRow(stmt, id).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUserModelInsertAuthenticate(t *testing.T) {
+	m := &UserModel{DB: newTestDB(t)}
+
+	err := m.Insert("Alice", "alice@example.com", "pa$$word")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.Insert("Alice Again", "alice@example.com", "pa$$word")
+	if !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("duplicate insert: got %v; want ErrDuplicateEmail", err)
+	}
+
+	id, err := m.Authenticate("alice@example.com", "pa$$word")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id < 1 {
+		t.Errorf("Authenticate() = %d; want a positive ID", id)
+	}
+
+	_, err = m.Authenticate("alice@example.com", "wrongPa$$word")
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password: got %v; want ErrInvalidCredentials", err)
+	}
+}
+
+func TestUserModelExists(t *testing.T) {
+	m := &UserModel{DB: newTestDB(t)}
+
+	if err := m.Insert("Alice", "alice@example.com", "pa$$word"); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := m.Authenticate("alice@example.com", "pa$$word")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		id   int
+		want bool
+	}{
+		{"Existing ID", id, true},
+		{"Zero ID", 0, false},
+		{"Non-existent ID", id + 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exists, err := m.Exists(tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exists != tt.want {
+				t.Errorf("Exists(%d) = %t; want %t", tt.id, exists, tt.want)
+			}
+		})
+	}
+}
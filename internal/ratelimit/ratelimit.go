@@ -0,0 +1,104 @@
+// Package ratelimit provides a token-bucket rate limiter keyed by client
+// address, for protecting the application from abusive traffic. Each
+// address gets its own bucket; buckets idle for a few minutes are dropped
+// by a background sweeper so memory stays bounded.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// idleTTL is how long an address's bucket survives without traffic
+	// before the sweeper drops it.
+	idleTTL = 3 * time.Minute
+
+	// sweepInterval is how often idle buckets are dropped.
+	sweepInterval = time.Minute
+)
+
+// client is one address's bucket and the last time it was used.
+type client struct {
+	lim      *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter rate-limits callers by key using one token bucket per key.
+type Limiter struct {
+	rps   rate.Limit
+	burst int
+
+	mu      sync.Mutex
+	clients map[string]*client
+	stop    chan struct{}
+}
+
+// New returns a limiter allowing rps sustained requests per second with
+// bursts up to burst, per key. Stop must be called when the limiter is
+// no longer needed.
+func New(rps float64, burst int) *Limiter {
+	l := &Limiter{
+		rps:     rate.Limit(rps),
+		burst:   burst,
+		clients: map[string]*client{},
+		stop:    make(chan struct{}),
+	}
+
+	go l.sweep()
+
+	return l
+}
+
+// Allow reports whether the key may proceed now and, when it may not,
+// how long the caller should wait before retrying.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	c, ok := l.clients[key]
+	if !ok {
+		c = &client{lim: rate.NewLimiter(l.rps, l.burst)}
+		l.clients[key] = c
+	}
+	c.lastSeen = time.Now()
+	l.mu.Unlock()
+
+	r := c.lim.Reserve()
+	if !r.OK() {
+		return false, time.Second
+	}
+
+	if delay := r.Delay(); delay > 0 {
+		r.Cancel()
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// Stop ends the background sweeper.
+func (l *Limiter) Stop() {
+	close(l.stop)
+}
+
+// sweep periodically drops buckets for addresses that have gone quiet.
+func (l *Limiter) sweep() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.mu.Lock()
+			for k, c := range l.clients {
+				if time.Since(c.lastSeen) > idleTTL {
+					delete(l.clients, k)
+				}
+			}
+			l.mu.Unlock()
+		case <-l.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,311 @@
+// Package tasks provides a worker-pool task queue persisted in the tasks
+// database table, so slow work (emails, webhooks, imports) runs outside
+// the request path and survives restarts. Failed tasks are retried with
+// exponential backoff; once the attempt limit is reached they move to a
+// dead-letter state where they no longer run but stay visible for
+// inspection. Completed and dead tasks are kept until Purge removes
+// them.
+package tasks
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// pollInterval is how often the queue looks for due tasks.
+	pollInterval = 5 * time.Second
+
+	// lease is how long a claimed task is invisible to other polls, so a
+	// crash mid-task means a redelivery rather than a lost task.
+	lease = 5 * time.Minute
+
+	// maxAttempts is how many times a task is tried before being moved
+	// to the dead-letter state.
+	maxAttempts = 5
+
+	// batchSize caps how many due tasks one poll claims.
+	batchSize = 20
+)
+
+// Handler processes one task's payload, as passed to Enqueue.
+type Handler func(payload []byte) error
+
+// task is one claimed row from the tasks table.
+type task struct {
+	id       int
+	kind     string
+	payload  []byte
+	attempts int
+}
+
+// Queue dispatches persisted tasks to a pool of worker goroutines.
+// Handlers must be registered before Start is called.
+type Queue struct {
+	// OnError, if set, is called when a task attempt fails. Permanent
+	// reports whether the task has exhausted its attempts and been
+	// dead-lettered.
+	OnError func(kind string, err error, permanent bool)
+
+	db       *sql.DB
+	workers  int
+	handlers map[string]Handler
+
+	work chan task
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewQueue returns a queue that will run the given number of workers
+// against the tasks table in db.
+func NewQueue(db *sql.DB, workers int) *Queue {
+	return &Queue{
+		db:       db,
+		workers:  workers,
+		handlers: map[string]Handler{},
+		work:     make(chan task),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Handle registers the handler for a task kind.
+func (q *Queue) Handle(kind string, h Handler) {
+	q.handlers[kind] = h
+}
+
+// Enqueue persists a task for asynchronous processing. The payload is
+// JSON-encoded and handed to the kind's handler as raw bytes.
+func (q *Queue) Enqueue(kind string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	_, err = q.db.Exec(`INSERT INTO tasks (kind, payload, attempts, run_at, created)
+	VALUES(?, ?, 0, ?, ?)`, kind, data, now, now)
+
+	return err
+}
+
+// Start launches the poller and worker goroutines.
+func (q *Queue) Start() {
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+
+	q.wg.Add(1)
+	go q.poll()
+}
+
+// Stop tells the poller and workers to exit and waits for them. Tasks
+// already claimed are finished first.
+func (q *Queue) Stop() {
+	close(q.stop)
+	q.wg.Wait()
+}
+
+// poll periodically claims due tasks and feeds them to the workers.
+func (q *Queue) poll() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			claimed, err := q.claim()
+			if err != nil {
+				q.reportError("poll", err, false)
+				continue
+			}
+
+			for _, t := range claimed {
+				select {
+				case q.work <- t:
+				case <-q.stop:
+					return
+				}
+			}
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+// claim selects due tasks and pushes their run_at into the future, acting
+// as a visibility timeout so a crashed worker's tasks come back.
+func (q *Queue) claim() ([]task, error) {
+	now := time.Now().UTC()
+
+	rows, err := q.db.Query(`SELECT id, kind, payload, attempts FROM tasks
+	WHERE run_at <= ? AND done_at IS NULL AND dead_at IS NULL ORDER BY id LIMIT ?`, now, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claimed []task
+
+	for rows.Next() {
+		var t task
+		if err := rows.Scan(&t.id, &t.kind, &t.payload, &t.attempts); err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, t := range claimed {
+		_, err := q.db.Exec("UPDATE tasks SET run_at = ? WHERE id = ?", now.Add(lease), t.id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return claimed, nil
+}
+
+// worker processes tasks until the queue is stopped.
+func (q *Queue) worker() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case t := <-q.work:
+			q.process(t)
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+// process runs one task attempt and records the outcome: done on
+// success, retry with backoff on failure, dead-letter after
+// maxAttempts.
+func (q *Queue) process(t task) {
+	h, ok := q.handlers[t.kind]
+	if !ok {
+		q.finish(t, fmt.Errorf("no handler registered for task kind %q", t.kind))
+		return
+	}
+
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		err = h(t.payload)
+	}()
+
+	q.finish(t, err)
+}
+
+// finish records a task attempt's outcome. Finished tasks are kept,
+// marked done or dead, so recent deliveries and failures can be
+// inspected; Purge removes them eventually.
+func (q *Queue) finish(t task, err error) {
+	now := time.Now().UTC()
+
+	if err == nil {
+		attempts := t.attempts + 1
+		if _, uerr := q.db.Exec("UPDATE tasks SET attempts = ?, done_at = ? WHERE id = ?",
+			attempts, now, t.id); uerr != nil {
+			q.reportError(t.kind, uerr, false)
+		}
+		return
+	}
+
+	attempts := t.attempts + 1
+	if attempts >= maxAttempts {
+		_, uerr := q.db.Exec("UPDATE tasks SET attempts = ?, dead_at = ?, last_error = ? WHERE id = ?",
+			attempts, now, err.Error(), t.id)
+		if uerr != nil {
+			q.reportError(t.kind, uerr, false)
+		}
+		q.reportError(t.kind, err, true)
+		return
+	}
+
+	// Exponential backoff: 30s, 1m, 2m, 4m between attempts.
+	delay := 30 * time.Second << (attempts - 1)
+
+	_, uerr := q.db.Exec("UPDATE tasks SET attempts = ?, run_at = ?, last_error = ? WHERE id = ?",
+		attempts, now.Add(delay), err.Error(), t.id)
+	if uerr != nil {
+		q.reportError(t.kind, uerr, false)
+	}
+
+	q.reportError(t.kind, err, false)
+}
+
+func (q *Queue) reportError(kind string, err error, permanent bool) {
+	if q.OnError != nil {
+		q.OnError(kind, err, permanent)
+	}
+}
+
+// TaskInfo describes one task for the admin dashboard: pending,
+// delivered or dead-lettered, with the last error seen for the latter
+// two when an attempt has failed.
+type TaskInfo struct {
+	ID        int
+	Kind      string
+	Attempts  int
+	Created   time.Time
+	Done      bool
+	Dead      bool
+	LastError string
+}
+
+// Recent returns the most recently enqueued tasks, newest first,
+// regardless of state.
+func (q *Queue) Recent(limit int) ([]TaskInfo, error) {
+	rows, err := q.db.Query(`SELECT id, kind, attempts, created, done_at, dead_at, last_error FROM tasks
+	ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var infos []TaskInfo
+
+	for rows.Next() {
+		var (
+			info           TaskInfo
+			doneAt, deadAt sql.NullTime
+			lastError      sql.NullString
+		)
+		if err := rows.Scan(&info.ID, &info.Kind, &info.Attempts, &info.Created, &doneAt, &deadAt, &lastError); err != nil {
+			return nil, err
+		}
+		info.Done = doneAt.Valid
+		info.Dead = deadAt.Valid
+		info.LastError = lastError.String
+		infos = append(infos, info)
+	}
+
+	return infos, rows.Err()
+}
+
+// Purge removes done and dead-lettered tasks that finished before the
+// cutoff, returning how many rows were removed. Pending tasks are never
+// touched.
+func (q *Queue) Purge(cutoff time.Time) (int64, error) {
+	result, err := q.db.Exec(`DELETE FROM tasks
+	WHERE (done_at IS NOT NULL AND done_at < ?) OR (dead_at IS NOT NULL AND dead_at < ?)`, cutoff, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,169 @@
+// Package validator provides simple form validation helpers.
+package validator
+
+import (
+	"cmp"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EmailRX is the regular expression recommended by the W3C and WHATWG for
+// validating email addresses.
+var EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+\\/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
+
+// SlugRX matches URL slugs: lowercase letters, digits and single hyphens,
+// neither starting nor ending with a hyphen.
+var SlugRX = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// Validator holds validation errors for a form, keyed by form field, along
+// with any errors not related to a specific field.
+type Validator struct {
+	NonFieldErrors []string
+	FieldErrors    map[string]string
+}
+
+// Valid reports whether the validator contains no errors.
+func (v *Validator) Valid() bool {
+	return len(v.FieldErrors) == 0 && len(v.NonFieldErrors) == 0
+}
+
+// AddNonFieldError appends an error message not associated with a specific
+// form field.
+func (v *Validator) AddNonFieldError(message string) {
+	v.NonFieldErrors = append(v.NonFieldErrors, message)
+}
+
+// AddFieldError adds an error message for the given form field, provided no
+// error already exists for that field.
+func (v *Validator) AddFieldError(key, message string) {
+	if v.FieldErrors == nil {
+		v.FieldErrors = make(map[string]string)
+	}
+
+	if _, exists := v.FieldErrors[key]; !exists {
+		v.FieldErrors[key] = message
+	}
+}
+
+// CheckField adds an error message for the given field only if the
+// validation check is not ok.
+func (v *Validator) CheckField(ok bool, key, message string) {
+	if !ok {
+		v.AddFieldError(key, message)
+	}
+}
+
+// CheckForm applies a cross-field rule: when ok is false the message is
+// attached to the named field, or recorded as a non-field error when
+// field is empty. It reads like CheckField but is meant for rules that
+// span several inputs — a confirmation not matching its password, an
+// expiry before a publish date — where the natural place to surface the
+// error still is one particular field.
+func (v *Validator) CheckForm(ok bool, field, message string) {
+	if ok {
+		return
+	}
+
+	if field == "" {
+		v.AddNonFieldError(message)
+	} else {
+		v.AddFieldError(field, message)
+	}
+}
+
+// NotBlank reports whether value contains at least one non-whitespace
+// character.
+func NotBlank(value string) bool {
+	return strings.TrimSpace(value) != ""
+}
+
+// MaxChars reports whether value contains no more than n characters.
+func MaxChars(value string, n int) bool {
+	return utf8.RuneCountInString(value) <= n
+}
+
+// MinChars reports whether value contains at least n characters.
+func MinChars(value string, n int) bool {
+	return utf8.RuneCountInString(value) >= n
+}
+
+// PermittedValue reports whether value is contained in the list of
+// permitted values.
+func PermittedValue[T comparable](value T, permittedValues ...T) bool {
+	for i := range permittedValues {
+		if value == permittedValues[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether value matches the provided regular expression.
+func Matches(value string, rx *regexp.Regexp) bool {
+	return rx.MatchString(value)
+}
+
+// ValidURL reports whether value parses as an absolute http or https URL
+// with a host.
+func ValidURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// InRange reports whether value lies between lo and hi inclusive.
+func InRange[T cmp.Ordered](value, lo, hi T) bool {
+	return value >= lo && value <= hi
+}
+
+// PermittedStringFold reports whether value equals one of the permitted
+// strings, ignoring case.
+func PermittedStringFold(value string, permitted ...string) bool {
+	for _, p := range permitted {
+		if strings.EqualFold(value, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidUTF8 reports whether value is well-formed UTF-8.
+func ValidUTF8(value string) bool {
+	return utf8.ValidString(value)
+}
+
+// NoControlChars reports whether value is free of control characters,
+// allowing tab, newline and carriage return.
+func NoControlChars(value string) bool {
+	for _, r := range value {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// Slug reports whether value is a well-formed URL slug.
+func Slug(value string) bool {
+	return SlugRX.MatchString(value)
+}
+
+// Equal reports whether two values are equal, for confirmation fields.
+func Equal[T comparable](a, b T) bool {
+	return a == b
+}
+
+// After reports whether t is after u, for rules that order two dates.
+func After(t, u time.Time) bool {
+	return t.After(u)
+}
+
+// Date parses value with the given layout, reporting whether it is a
+// real calendar date.
+func Date(value, layout string) (time.Time, bool) {
+	t, err := time.Parse(layout, value)
+	return t, err == nil
+}
@@ -0,0 +1,207 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestValidURL(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"https://example.com", true},
+		{"http://example.com/path?q=1", true},
+		{"ftp://example.com", false},
+		{"https://", false},
+		{"example.com", false},
+		{"not a url", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidURL(tt.value); got != tt.want {
+			t.Errorf("ValidURL(%q) = %v; want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestInRange(t *testing.T) {
+	if !InRange(5, 1, 10) {
+		t.Error("InRange(5, 1, 10) = false; want true")
+	}
+	if !InRange(1, 1, 10) || !InRange(10, 1, 10) {
+		t.Error("InRange should include the bounds")
+	}
+	if InRange(0, 1, 10) || InRange(11, 1, 10) {
+		t.Error("InRange should exclude values outside the bounds")
+	}
+	if !InRange(2.5, 1.0, 3.0) {
+		t.Error("InRange(2.5, 1.0, 3.0) = false; want true")
+	}
+	if !InRange("b", "a", "c") {
+		t.Error(`InRange("b", "a", "c") = false; want true`)
+	}
+}
+
+func TestPermittedStringFold(t *testing.T) {
+	if !PermittedStringFold("Lax", "lax", "strict", "none") {
+		t.Error(`PermittedStringFold("Lax") = false; want true`)
+	}
+	if PermittedStringFold("other", "lax", "strict", "none") {
+		t.Error(`PermittedStringFold("other") = true; want false`)
+	}
+	if PermittedStringFold("lax") {
+		t.Error("PermittedStringFold with no permitted values should be false")
+	}
+}
+
+func TestValidUTF8(t *testing.T) {
+	if !ValidUTF8("héllo wörld") {
+		t.Error("ValidUTF8 rejected valid UTF-8")
+	}
+	if ValidUTF8(string([]byte{0xff, 0xfe})) {
+		t.Error("ValidUTF8 accepted invalid bytes")
+	}
+}
+
+func TestNoControlChars(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"plain text", true},
+		{"tabs\tand\nnewlines\r\n", true},
+		{"null\x00byte", false},
+		{"escape\x1b[31m", false},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		if got := NoControlChars(tt.value); got != tt.want {
+			t.Errorf("NoControlChars(%q) = %v; want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"hello", true},
+		{"hello-world-2", true},
+		{"Hello", false},
+		{"-hello", false},
+		{"hello-", false},
+		{"hello--world", false},
+		{"hello world", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := Slug(tt.value); got != tt.want {
+			t.Errorf("Slug(%q) = %v; want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCheckForm(t *testing.T) {
+	var v Validator
+
+	v.CheckForm(true, "password_confirm", "should not appear")
+	if !v.Valid() {
+		t.Error("CheckForm(true) added an error; want none")
+	}
+
+	v.CheckForm(false, "password_confirm", "Passwords do not match")
+	if got := v.FieldErrors["password_confirm"]; got != "Passwords do not match" {
+		t.Errorf("FieldErrors[password_confirm] = %q; want mismatch message", got)
+	}
+
+	v.CheckForm(false, "", "form-level problem")
+	if len(v.NonFieldErrors) != 1 || v.NonFieldErrors[0] != "form-level problem" {
+		t.Errorf("NonFieldErrors = %v; want the form-level problem", v.NonFieldErrors)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	if !Equal("pa55word", "pa55word") {
+		t.Error("Equal on identical strings = false; want true")
+	}
+	if Equal("pa55word", "pa55w0rd") {
+		t.Error("Equal on different strings = true; want false")
+	}
+	if !Equal(7, 7) {
+		t.Error("Equal(7, 7) = false; want true")
+	}
+}
+
+func TestAfter(t *testing.T) {
+	publish := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	expiry := publish.AddDate(0, 0, 7)
+
+	if !After(expiry, publish) {
+		t.Error("After(expiry, publish) = false; want true")
+	}
+	if After(publish, expiry) {
+		t.Error("After(publish, expiry) = true; want false")
+	}
+	if After(publish, publish) {
+		t.Error("After on equal times = true; want false")
+	}
+}
+
+func TestDate(t *testing.T) {
+	got, ok := Date("2024-02-29", "2006-01-02")
+	if !ok {
+		t.Fatal(`Date("2024-02-29") not ok; want ok`)
+	}
+	if want := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Date() = %v; want %v", got, want)
+	}
+
+	if _, ok := Date("2023-02-29", "2006-01-02"); ok {
+		t.Error(`Date("2023-02-29") ok; want not ok`)
+	}
+	if _, ok := Date("nonsense", "2006-01-02"); ok {
+		t.Error(`Date("nonsense") ok; want not ok`)
+	}
+}
+
+func FuzzMaxMinChars(f *testing.F) {
+	f.Add("", 0)
+	f.Add("hello", 5)
+	f.Add("héllo wörld", 11)
+	f.Add("日本語のテキスト", 8)
+	f.Add("🙂🙃", 1)
+	f.Add(string([]byte{0xff, 0xfe}), 2)
+
+	f.Fuzz(func(t *testing.T, value string, n int) {
+		runes := utf8.RuneCountInString(value)
+
+		if got, want := MaxChars(value, n), runes <= n; got != want {
+			t.Errorf("MaxChars(%q, %d) = %v; want %v (%d runes)", value, n, got, want, runes)
+		}
+		if got, want := MinChars(value, n), runes >= n; got != want {
+			t.Errorf("MinChars(%q, %d) = %v; want %v (%d runes)", value, n, got, want, runes)
+		}
+	})
+}
+
+func FuzzEmailRX(f *testing.F) {
+	f.Add("alice@example.com")
+	f.Add("alice")
+	f.Add("@example.com")
+	f.Add("a@b")
+	f.Add("héllo@exämple.com")
+	f.Add(string([]byte{0xff, '@', 0xfe}))
+
+	f.Fuzz(func(t *testing.T, value string) {
+		if Matches(value, EmailRX) && !strings.Contains(value, "@") {
+			t.Errorf("EmailRX matched %q, which has no @", value)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+// Package version reports which build of the application is running.
+package version
+
+import "runtime/debug"
+
+// number is the human-facing version number, intended to be overridden at
+// build time:
+//
+//	go build -ldflags "-X snippetbox.ayubasayyed.net/internal/version.number=1.2.3"
+var number = "dev"
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the build information for the running binary, combining the
+// ldflags-provided version number with the VCS details recorded by the Go
+// toolchain.
+func Get() Info {
+	info := Info{
+		Version:   number,
+		Commit:    "unknown",
+		BuildTime: "unknown",
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.GoVersion = bi.GoVersion
+
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.time":
+			info.BuildTime = setting.Value
+		case "vcs.modified":
+			if setting.Value == "true" {
+				info.Commit += "-dirty"
+			}
+		}
+	}
+
+	return info
+}
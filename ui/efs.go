@@ -0,0 +1,9 @@
+// Package ui holds the embedded user interface assets, so the compiled
+// binary carries its own templates and static files and can be copied
+// anywhere without the source tree.
+package ui
+
+import "embed"
+
+//go:embed "html" "pages" "static"
+var Files embed.FS
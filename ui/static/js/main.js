@@ -0,0 +1,66 @@
+// The application may be mounted under a base path; the base template
+// exposes it so URLs built here stay correct.
+var basePathMeta = document.querySelector("meta[name=base-path]");
+var basePath = basePathMeta ? basePathMeta.getAttribute("content") : "";
+
+var navLinks = document.querySelectorAll("nav a");
+for (var i = 0; i < navLinks.length; i++) {
+    var link = navLinks[i]
+    if (link.getAttribute('href') == window.location.pathname) {
+        link.classList.add("live");
+        break;
+    }
+}
+
+// On the home page, open a WebSocket to the firehose and prepend newly
+// created snippets to the latest snippets table as they arrive.
+var latestTable = document.querySelector("main table");
+if (latestTable && window.location.pathname == basePath + "/") {
+    var scheme = window.location.protocol == "https:" ? "wss:" : "ws:";
+    var socket = new WebSocket(scheme + "//" + window.location.host + basePath + "/ws/firehose");
+    socket.onmessage = function (e) {
+        var snippet = JSON.parse(e.data);
+        var row = latestTable.insertRow(1);
+        var title = row.insertCell(0);
+        var link = document.createElement("a");
+        link.href = basePath + "/snippet/view/" + snippet.id;
+        link.textContent = snippet.title;
+        title.appendChild(link);
+        row.insertCell(1).textContent = new Date(snippet.created).toLocaleString();
+        row.insertCell(2).textContent = "#" + snippet.id;
+    };
+}
+
+// On the snippet view page, subscribe to the comment event stream and
+// append new comments to the list as they're posted.
+var commentSection = document.querySelector("section.comments");
+if (commentSection && window.EventSource) {
+    var snippetId = commentSection.getAttribute("data-snippet-id");
+    var source = new EventSource(basePath + "/snippet/events/" + snippetId);
+    source.addEventListener("comment", function (e) {
+        var comment = JSON.parse(e.data);
+        var item = document.createElement("li");
+        var author = document.createElement("strong");
+        author.textContent = comment.author;
+        var when = document.createElement("time");
+        when.textContent = new Date(comment.created).toLocaleString();
+        var body = document.createElement("p");
+        body.textContent = comment.body;
+        item.appendChild(author);
+        item.appendChild(when);
+        item.appendChild(body);
+        document.getElementById("comment-list").appendChild(item);
+    });
+}
+
+// On the snippet view page, copy the snippet to the clipboard and fire
+// a beacon so the server can bump the copy counter in its next batch.
+var copyButton = document.querySelector("button.copy-snippet");
+if (copyButton && navigator.clipboard) {
+    copyButton.addEventListener("click", function () {
+        var code = document.querySelector(".snippet pre code");
+        navigator.clipboard.writeText(code.textContent).then(function () {
+            navigator.sendBeacon(basePath + "/snippet/copied/" + copyButton.getAttribute("data-id"));
+        });
+    });
+}